@@ -0,0 +1,32 @@
+package paypal
+
+import (
+	"strings"
+
+	"github.com/japhy-team/paypal/internal/amount"
+)
+
+// zeroDecimalCurrencies are the currencies PayPal does not accept decimal amounts in
+var zeroDecimalCurrencies = map[string]bool{"HUF": true, "JPY": true, "TWD": true}
+
+// currencyDecimals returns the number of decimals of amounts in the currency
+func currencyDecimals(currencyCode string) int {
+	if zeroDecimalCurrencies[strings.ToUpper(currencyCode)] {
+		return 0
+	}
+	return 2
+}
+
+// parseAmount converts a decimal amount such as "10.50" into the minor unit of the currency, such as 1050 cents
+func parseAmount(value, currencyCode string) (int64, error) {
+	units, ok := amount.Parse(value, currencyDecimals(currencyCode))
+	if !ok {
+		return 0, newValidationError(amount.Invalid(value, currencyCode))
+	}
+	return units, nil
+}
+
+// formatAmount converts an amount in the minor unit of the currency back into the decimal format PayPal expects
+func formatAmount(units int64, currencyCode string) string {
+	return amount.Format(units, currencyDecimals(currencyCode))
+}
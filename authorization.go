@@ -0,0 +1,357 @@
+package paypal
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// These constants are the periods PayPal guarantees an authorization for. PayPal honors the funds of an authorization
+// for the HonorPeriod, and the authorization can be captured until its AuthorizationValidity runs out, though
+// a capture past the honor period may fail for lack of funds. A reauthorization starts a new honor period but does not
+// extend the validity of the original authorization
+const (
+	HonorPeriod           = 3 * 24 * time.Hour
+	AuthorizationValidity = 29 * 24 * time.Hour
+)
+
+// ShippingAddress is the address a payment ships to
+type ShippingAddress struct {
+	Name        string `json:"shiptoname,omitempty"`
+	Street      string `json:"shiptostreet,omitempty"`
+	Street2     string `json:"shiptostreet2,omitempty"`
+	City        string `json:"shiptocity,omitempty"`
+	State       string `json:"shiptostate,omitempty"`
+	Zip         string `json:"shiptozip,omitempty"`
+	CountryCode string `json:"shiptocountry,omitempty"`
+	PhoneNumber string `json:"shiptophonenum,omitempty"`
+}
+
+// apply adds the address to the request values
+func (a ShippingAddress) apply(values url.Values) {
+	for key, value := range map[string]string{
+		"SHIPTONAME":     a.Name,
+		"SHIPTOSTREET":   a.Street,
+		"SHIPTOSTREET2":  a.Street2,
+		"SHIPTOCITY":     a.City,
+		"SHIPTOSTATE":    a.State,
+		"SHIPTOZIP":      a.Zip,
+		"SHIPTOCOUNTRY":  a.CountryCode,
+		"SHIPTOPHONENUM": a.PhoneNumber,
+	} {
+		if len(value) != 0 {
+			values.Set(key, value)
+		}
+	}
+}
+
+// AuthorizationResult is the typed response of DoAuthorization, DoReauthorization and UpdateAuthorization.
+// CurrencyCode is the one of the request, PayPal does not send it back
+type AuthorizationResult struct {
+	AuthorizationID           string        `json:"authorizationid,omitempty"`
+	Amount                    string        `json:"amt,omitempty"`
+	CurrencyCode              string        `json:"currencycode,omitempty"`
+	PaymentStatus             PaymentStatus `json:"paymentstatus,omitempty"`
+	PendingReason             PendingReason `json:"pendingreason,omitempty"`
+	ProtectionEligibility     string        `json:"protectioneligibility,omitempty"`
+	ProtectionEligibilityType string        `json:"protectioneligibilitytype,omitempty"`
+	MessageID                 string        `json:"msgsubid,omitempty"`
+	Timestamp                 time.Time     `json:"timestamp"`
+}
+
+// Authorization tracks an authorization through its lifecycle: its honor period, its validity, the amount captured
+// from it so far and whether it can still be voided. ID is the ID of the last reauthorization, if any, and OriginalID
+// the one of the authorization itself. Amounts are in CurrencyCode.
+// Record the captures, voids and reauthorizations made outside CaptureAuthorization, VoidAuthorization and
+// ReauthorizeAuthorization with RecordCapture, RecordVoid and RecordReauthorization
+// Those three record what PayPal processed even when they return a *ResponseParseError along with its response
+type Authorization struct {
+	ID               string    `json:"id"`
+	OriginalID       string    `json:"originalid"`
+	Amount           string    `json:"amt"`
+	CurrencyCode     string    `json:"currencycode"`
+	AuthorizedAt     time.Time `json:"authorizedat"`
+	HonorPeriodStart time.Time `json:"honorperiodstart"`
+	CapturedAmount   string    `json:"capturedamt,omitempty"`
+	Reauthorized     bool      `json:"reauthorized,omitempty"`
+	Completed        bool      `json:"completed,omitempty"`
+	Voided           bool      `json:"voided,omitempty"`
+}
+
+// NewAuthorization tracks the authorization with the ID of amount made at authorizedAt, such as the one returned by
+// DoExpressCheckoutPayment with the Authorization payment action
+func NewAuthorization(id, amount, currencyCode string, authorizedAt time.Time) *Authorization {
+	return &Authorization{
+		ID:               id,
+		OriginalID:       id,
+		Amount:           amount,
+		CurrencyCode:     currencyCode,
+		AuthorizedAt:     authorizedAt.UTC(),
+		HonorPeriodStart: authorizedAt.UTC(),
+	}
+}
+
+// Authorization tracks the authorization of the result of DoAuthorization. When PayPal's timestamp is missing or could
+// not be parsed, the authorization is dated from now
+func (r *AuthorizationResult) Authorization() *Authorization {
+	return NewAuthorization(r.AuthorizationID, r.Amount, r.CurrencyCode, timestampOrNow(r.Timestamp))
+}
+
+// timestampOrNow returns the timestamp of a response, or now when it is zero because PayPal's could not be parsed
+func timestampOrNow(timestamp time.Time) time.Time {
+	if timestamp.IsZero() {
+		return time.Now()
+	}
+	return timestamp
+}
+
+// HonorPeriodEnd returns the time the funds stop being honored
+func (a *Authorization) HonorPeriodEnd() time.Time {
+	return a.HonorPeriodStart.Add(HonorPeriod)
+}
+
+// ExpiresAt returns the time the authorization can no longer be captured
+func (a *Authorization) ExpiresAt() time.Time {
+	return a.AuthorizedAt.Add(AuthorizationValidity)
+}
+
+// IsHonored reports whether the funds are still honored at now
+func (a *Authorization) IsHonored(now time.Time) bool {
+	return now.Before(a.HonorPeriodEnd()) && !a.IsExpired(now)
+}
+
+// IsExpired reports whether the authorization expired at now
+func (a *Authorization) IsExpired(now time.Time) bool {
+	return !now.Before(a.ExpiresAt())
+}
+
+// Remaining returns the amount that can still be captured
+func (a *Authorization) Remaining() (string, error) {
+	remaining, err := a.remaining()
+	if err != nil {
+		return "", err
+	}
+	return formatAmount(remaining, a.CurrencyCode), nil
+}
+
+func (a *Authorization) remaining() (int64, error) {
+	amount, err := parseAmount(a.Amount, a.CurrencyCode)
+	if err != nil {
+		return 0, err
+	}
+	if len(a.CapturedAmount) == 0 {
+		return amount, nil
+	}
+	captured, err := parseAmount(a.CapturedAmount, a.CurrencyCode)
+	if err != nil {
+		return 0, err
+	}
+	return amount - captured, nil
+}
+
+// IsOpen reports whether some of the authorization can still be captured at now
+func (a *Authorization) IsOpen(now time.Time) bool {
+	remaining, err := a.remaining()
+	return err == nil && remaining > 0 && !a.Completed && !a.Voided && !a.IsExpired(now)
+}
+
+// CanVoid reports whether DoVoid is still allowed at now, voiding what was not captured yet
+func (a *Authorization) CanVoid(now time.Time) bool {
+	return a.IsOpen(now)
+}
+
+// CanReauthorize reports whether DoReauthorization is allowed at now. PayPal allows a single reauthorization, once the
+// honor period is over: it rejects the ones within the honor period with its error 10617
+func (a *Authorization) CanReauthorize(now time.Time) bool {
+	return a.IsOpen(now) && !a.Reauthorized && !now.Before(a.HonorPeriodEnd())
+}
+
+// RecordCapture adds a capture of amount to the amount captured so far. complete is the capture that closed the
+// authorization (COMPLETETYPE=Complete)
+func (a *Authorization) RecordCapture(amount string, complete bool) error {
+	units, err := parseAmount(amount, a.CurrencyCode)
+	if err != nil {
+		return err
+	}
+	captured := int64(0)
+	if len(a.CapturedAmount) != 0 {
+		if captured, err = parseAmount(a.CapturedAmount, a.CurrencyCode); err != nil {
+			return err
+		}
+	}
+	a.CapturedAmount = formatAmount(captured+units, a.CurrencyCode)
+	a.Completed = a.Completed || complete
+	return nil
+}
+
+// RecordVoid records that the authorization was voided
+func (a *Authorization) RecordVoid() {
+	a.Voided = true
+}
+
+// RecordReauthorization records the result of DoReauthorization, which starts a new honor period. When PayPal's
+// timestamp is missing or could not be parsed, the honor period starts now
+func (a *Authorization) RecordReauthorization(result *AuthorizationResult) {
+	a.ID = result.AuthorizationID
+	a.HonorPeriodStart = timestampOrNow(result.Timestamp).UTC()
+	a.Reauthorized = true
+}
+
+// DoAuthorization authorizes a payment against an order, the one made by DoExpressCheckoutPayment with the Order
+// payment action
+// See https://developer.paypal.com/docs/classic/api/merchant/DoAuthorization-API-Operation-NVP/ for details
+func (pClient *PayPalClient) DoAuthorization(orderID, amount, currencyCode, messageID string) (*AuthorizationResult, error) {
+	values := url.Values{}
+	values.Set("METHOD", "DoAuthorization")
+	values.Set("TRANSACTIONID", orderID)
+	values.Set("TRANSACTIONENTITY", "Order")
+	values.Set("AMT", amount)
+	values.Set("CURRENCYCODE", currencyCode)
+	if len(messageID) != 0 {
+		values.Set("MSGSUBID", messageID)
+	}
+
+	result, err := pClient.performAuthorization(values, "TRANSACTIONID")
+	if requestFailed(err) {
+		return nil, err
+	}
+	result.CurrencyCode = currencyCode
+	return result, err
+}
+
+// DoReauthorization reauthorizes an authorization once its honor period is over, for up to 115% of
+// the original amount. The result carries the ID of the new authorization
+// See https://developer.paypal.com/docs/classic/api/merchant/DoReauthorization-API-Operation-NVP/ for details
+func (pClient *PayPalClient) DoReauthorization(authorizationID, amount, currencyCode, messageID string) (*AuthorizationResult, error) {
+	values := url.Values{}
+	values.Set("METHOD", "DoReauthorization")
+	values.Set("AUTHORIZATIONID", authorizationID)
+	values.Set("AMT", amount)
+	values.Set("CURRENCYCODE", currencyCode)
+	if len(messageID) != 0 {
+		values.Set("MSGSUBID", messageID)
+	}
+
+	result, err := pClient.performAuthorization(values, "AUTHORIZATIONID")
+	if requestFailed(err) {
+		return nil, err
+	}
+	result.Amount = amount
+	result.CurrencyCode = currencyCode
+	return result, err
+}
+
+// UpdateAuthorization updates the shipping address of an authorization, which PayPal checks against its
+// seller protection policy
+// See https://developer.paypal.com/docs/classic/api/merchant/UpdateAuthorization-API-Operation-NVP/ for details
+func (pClient *PayPalClient) UpdateAuthorization(authorizationID string, shipTo ShippingAddress) (*AuthorizationResult, error) {
+	values := url.Values{}
+	values.Set("METHOD", "UpdateAuthorization")
+	values.Set("TRANSACTIONID", authorizationID)
+	shipTo.apply(values)
+
+	return pClient.performAuthorization(values, "TRANSACTIONID")
+}
+
+// performAuthorization sends an authorization request and reads the authorization ID of its response under idKey.
+// The result is returned along with a *ResponseParseError
+func (pClient *PayPalClient) performAuthorization(values url.Values, idKey string) (*AuthorizationResult, error) {
+	response, err := pClient.PerformRequest(values)
+	if requestFailed(err) {
+		return nil, err
+	}
+	return &AuthorizationResult{
+		AuthorizationID:           response.Values.Get(idKey),
+		Amount:                    response.Values.Get("AMT"),
+		PaymentStatus:             ParsePaymentStatus(response.Values.Get("PAYMENTSTATUS")),
+		PendingReason:             ParsePendingReason(response.Values.Get("PENDINGREASON")),
+		ProtectionEligibility:     response.Values.Get("PROTECTIONELIGIBILITY"),
+		ProtectionEligibilityType: response.Values.Get("PROTECTIONELIGIBILITYTYPE"),
+		MessageID:                 response.Values.Get("MSGSUBID"),
+		Timestamp:                 response.Timestamp,
+	}, err
+}
+
+// CaptureAuthorization captures amount from the authorization with DoCapture and records the capture.
+// An empty amount captures what remains of the authorization. complete closes the authorization once captured
+func (pClient *PayPalClient) CaptureAuthorization(a *Authorization, amount string, complete bool, invoiceID string) (*PayPalResponse, error) {
+	return pClient.captureAuthorization(a, amount, complete, invoiceID, time.Now())
+}
+
+// captureAuthorization is CaptureAuthorization at now
+func (pClient *PayPalClient) captureAuthorization(a *Authorization, amount string, complete bool, invoiceID string, now time.Time) (*PayPalResponse, error) {
+	if !a.IsOpen(now) {
+		return nil, newValidationError("the authorization " + a.ID + " can no longer be captured")
+	}
+	remaining, err := a.remaining()
+	if err != nil {
+		return nil, err
+	}
+	if len(amount) == 0 {
+		amount = formatAmount(remaining, a.CurrencyCode)
+	}
+	units, err := parseAmount(amount, a.CurrencyCode)
+	if err != nil {
+		return nil, err
+	}
+	if units > remaining {
+		return nil, newValidationError(fmt.Sprintf("cannot capture %s %s, %s remain of the authorization",
+			amount, a.CurrencyCode, formatAmount(remaining, a.CurrencyCode)))
+	}
+
+	values := captureValues(amount, a.ID, complete, invoiceID)
+	values.Set("CURRENCYCODE", a.CurrencyCode)
+	response, err := pClient.PerformRequest(values)
+	if requestFailed(err) {
+		return response, err
+	}
+	if recordErr := a.RecordCapture(amount, complete); recordErr != nil {
+		return response, recordErr
+	}
+	return response, err
+}
+
+// VoidAuthorization voids what was not captured of the authorization with DoVoid and records the void
+func (pClient *PayPalClient) VoidAuthorization(a *Authorization, note, messageID string) (*PayPalResponse, error) {
+	return pClient.voidAuthorization(a, note, messageID, time.Now())
+}
+
+// voidAuthorization is VoidAuthorization at now
+func (pClient *PayPalClient) voidAuthorization(a *Authorization, note, messageID string, now time.Time) (*PayPalResponse, error) {
+	if !a.CanVoid(now) {
+		return nil, newValidationError("the authorization " + a.ID + " can no longer be voided")
+	}
+	response, err := pClient.DoVoid(a.OriginalID, note, messageID)
+	if requestFailed(err) {
+		return response, err
+	}
+	a.RecordVoid()
+	return response, err
+}
+
+// ReauthorizeAuthorization reauthorizes what remains of the authorization with DoReauthorization and records the
+// new authorization
+func (pClient *PayPalClient) ReauthorizeAuthorization(a *Authorization, messageID string) (*AuthorizationResult, error) {
+	return pClient.reauthorizeAuthorization(a, messageID, time.Now())
+}
+
+// reauthorizeAuthorization is ReauthorizeAuthorization at now, which starts the new honor period when PayPal's
+// timestamp is missing or could not be parsed
+func (pClient *PayPalClient) reauthorizeAuthorization(a *Authorization, messageID string, now time.Time) (*AuthorizationResult, error) {
+	if !a.CanReauthorize(now) {
+		return nil, newValidationError("the authorization " + a.ID + " can no longer be reauthorized")
+	}
+	remaining, err := a.Remaining()
+	if err != nil {
+		return nil, err
+	}
+	result, err := pClient.DoReauthorization(a.OriginalID, remaining, a.CurrencyCode, messageID)
+	if requestFailed(err) {
+		return nil, err
+	}
+	if result.Timestamp.IsZero() {
+		result.Timestamp = now
+	}
+	a.RecordReauthorization(result)
+	return result, err
+}
@@ -0,0 +1,152 @@
+package paypal_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/japhy-team/paypal"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDoAuthorization(t *testing.T) {
+	var request url.Values
+	client := newTestClient(t, func(r url.Values) url.Values {
+		request = r
+		switch r.Get("METHOD") {
+		case "DoAuthorization":
+			return url.Values{"ACK": {"Success"}, "TIMESTAMP": {"2020-03-01T10:00:00Z"}, "TRANSACTIONID": {"AUTH1"},
+				"AMT": {"50.00"}, "PAYMENTSTATUS": {"Pending"}, "PENDINGREASON": {"authorization"}}
+		case "DoReauthorization":
+			return url.Values{"ACK": {"Success"}, "TIMESTAMP": {"2020-03-04T09:00:00Z"}, "AUTHORIZATIONID": {"AUTH2"},
+				"PAYMENTSTATUS": {"Pending"}, "PENDINGREASON": {"authorization"}}
+		default:
+			return url.Values{"ACK": {"Success"}, "TRANSACTIONID": {r.Get("TRANSACTIONID")}}
+		}
+	})
+
+	result, err := client.DoAuthorization("ORDER1", "50.00", "EUR", "MSG1")
+	assert.NoError(t, err)
+	assert.Equal(t, "ORDER1", request.Get("TRANSACTIONID"))
+	assert.Equal(t, "Order", request.Get("TRANSACTIONENTITY"))
+	assert.Equal(t, "EUR", request.Get("CURRENCYCODE"))
+	assert.Equal(t, "MSG1", request.Get("MSGSUBID"))
+	assert.Equal(t, "AUTH1", result.AuthorizationID)
+	assert.Equal(t, "EUR", result.CurrencyCode)
+	assert.Equal(t, paypal.PendingReasonAuthorization, result.PendingReason)
+
+	authorization := result.Authorization()
+	assert.Equal(t, time.Date(2020, 3, 4, 10, 0, 0, 0, time.UTC), authorization.HonorPeriodEnd())
+	assert.Equal(t, time.Date(2020, 3, 30, 10, 0, 0, 0, time.UTC), authorization.ExpiresAt())
+
+	result, err = client.DoReauthorization("AUTH1", "50.00", "EUR", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "AUTH1", request.Get("AUTHORIZATIONID"))
+	assert.NotContains(t, request, "MSGSUBID")
+	assert.Equal(t, "AUTH2", result.AuthorizationID)
+	authorization.RecordReauthorization(result)
+	assert.Equal(t, "AUTH2", authorization.ID)
+	assert.Equal(t, "AUTH1", authorization.OriginalID)
+	assert.Equal(t, time.Date(2020, 3, 7, 9, 0, 0, 0, time.UTC), authorization.HonorPeriodEnd())
+	assert.Equal(t, time.Date(2020, 3, 30, 10, 0, 0, 0, time.UTC), authorization.ExpiresAt())
+
+	_, err = client.UpdateAuthorization("AUTH2", paypal.ShippingAddress{Name: "Test Buyer", Street: "1 Main St", City: "San Jose", State: "CA", Zip: "95131", CountryCode: "US"})
+	assert.NoError(t, err)
+	assert.Equal(t, "UpdateAuthorization", request.Get("METHOD"))
+	assert.Equal(t, "1 Main St", request.Get("SHIPTOSTREET"))
+	assert.Equal(t, "US", request.Get("SHIPTOCOUNTRY"))
+	assert.NotContains(t, request, "SHIPTOSTREET2")
+}
+
+func TestAuthorizationLifecycle(t *testing.T) {
+	authorizedAt := time.Date(2020, 3, 1, 10, 0, 0, 0, time.UTC)
+	authorization := paypal.NewAuthorization("AUTH1", "100.00", "USD", authorizedAt)
+
+	assert.True(t, authorization.IsHonored(authorizedAt.Add(71*time.Hour)))
+	assert.False(t, authorization.IsHonored(authorizedAt.Add(72*time.Hour)))
+	assert.True(t, authorization.IsOpen(authorizedAt.Add(28*24*time.Hour)))
+	assert.True(t, authorization.IsExpired(authorizedAt.Add(29*24*time.Hour)))
+	assert.False(t, authorization.CanVoid(authorizedAt.Add(29*24*time.Hour)))
+
+	assert.NoError(t, authorization.RecordCapture("60.00", false))
+	assert.NoError(t, authorization.RecordCapture("15.5", false))
+	remaining, err := authorization.Remaining()
+	assert.NoError(t, err)
+	assert.Equal(t, "24.50", remaining)
+	assert.True(t, authorization.CanVoid(authorizedAt))
+	assert.False(t, authorization.CanReauthorize(authorizedAt.Add(71*time.Hour)))
+	assert.True(t, authorization.CanReauthorize(authorizedAt.Add(72*time.Hour)))
+
+	assert.NoError(t, authorization.RecordCapture("10.00", true))
+	assert.False(t, authorization.IsOpen(authorizedAt))
+	assert.False(t, authorization.CanVoid(authorizedAt))
+}
+
+func TestCaptureAndVoidAuthorization(t *testing.T) {
+	var requests []url.Values
+	client := newTestClient(t, func(r url.Values) url.Values {
+		requests = append(requests, r)
+		if r.Get("METHOD") == "DoReauthorization" {
+			return url.Values{"ACK": {"Success"}, "AUTHORIZATIONID": {"AUTH2"}}
+		}
+		return url.Values{"ACK": {"Success"}, "TRANSACTIONID": {"CAPTURE1"}}
+	})
+	authorization := paypal.NewAuthorization("AUTH1", "40.00", "EUR", time.Now().Add(-80*time.Hour))
+
+	_, err := client.ReauthorizeAuthorization(authorization, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "40.00", requests[0].Get("AMT"))
+	assert.True(t, authorization.IsHonored(time.Now()))
+	_, err = client.ReauthorizeAuthorization(authorization, "")
+	assert.IsType(t, &paypal.ValidationError{}, err)
+
+	_, err = client.CaptureAuthorization(authorization, "25.00", false, "INV1")
+	assert.NoError(t, err)
+	assert.Equal(t, "AUTH2", requests[1].Get("AUTHORIZATIONID"))
+	assert.Equal(t, "EUR", requests[1].Get("CURRENCYCODE"))
+	assert.Equal(t, "NotComplete", requests[1].Get("COMPLETETYPE"))
+	assert.Equal(t, "25.00", authorization.CapturedAmount)
+
+	_, err = client.CaptureAuthorization(authorization, "20.00", false, "INV1")
+	assert.IsType(t, &paypal.ValidationError{}, err)
+	assert.Len(t, requests, 2)
+
+	_, err = client.VoidAuthorization(authorization, "Out of stock", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "DoVoid", requests[2].Get("METHOD"))
+	assert.Equal(t, "AUTH1", requests[2].Get("AUTHORIZATIONID"))
+	assert.True(t, authorization.Voided)
+
+	_, err = client.CaptureAuthorization(authorization, "", true, "INV1")
+	assert.IsType(t, &paypal.ValidationError{}, err)
+	assert.Len(t, requests, 3)
+}
+
+func TestAuthorizationWithInvalidTimestamp(t *testing.T) {
+	client := newTestClient(t, func(r url.Values) url.Values {
+		if r.Get("METHOD") == "DoReauthorization" {
+			return url.Values{"ACK": {"Success"}, "TIMESTAMP": {"04/03/2020"}, "AUTHORIZATIONID": {"AUTH2"}}
+		}
+		return url.Values{"ACK": {"Success"}, "TIMESTAMP": {"01/03/2020"}, "TRANSACTIONID": {"AUTH1"}, "AMT": {"50.00"}}
+	})
+
+	result, err := client.DoAuthorization("ORDER1", "50.00", "EUR", "")
+	assert.IsType(t, &paypal.ResponseParseError{}, err)
+	authorization := result.Authorization()
+	// Dated from now rather than from year 1, so it is not taken for an expired authorization
+	assert.WithinDuration(t, time.Now(), authorization.AuthorizedAt, time.Minute)
+	assert.True(t, authorization.IsOpen(time.Now()))
+
+	authorization = paypal.NewAuthorization("AUTH1", "50.00", "EUR", time.Now().Add(-80*time.Hour))
+	reauthorization, err := client.ReauthorizeAuthorization(authorization, "")
+	assert.IsType(t, &paypal.ResponseParseError{}, err)
+	assert.Equal(t, "AUTH2", reauthorization.AuthorizationID)
+	assert.Equal(t, "AUTH2", authorization.ID)
+	assert.True(t, authorization.Reauthorized)
+	assert.WithinDuration(t, time.Now(), authorization.HonorPeriodStart, time.Minute)
+	assert.True(t, authorization.IsHonored(time.Now()))
+
+	authorization.RecordReauthorization(&paypal.AuthorizationResult{AuthorizationID: "AUTH3"})
+	assert.WithinDuration(t, time.Now(), authorization.HonorPeriodStart, time.Minute)
+}
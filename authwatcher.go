@@ -0,0 +1,291 @@
+package paypal
+
+import (
+	"sync"
+	"time"
+)
+
+// AuthorizationStore keeps the authorizations an AuthorizationWatcher watches, by their OriginalID
+type AuthorizationStore interface {
+	Save(a *Authorization) error
+	Delete(originalID string) error
+	List() ([]*Authorization, error)
+}
+
+// MemoryAuthorizationStore is an AuthorizationStore kept in memory, for tests and processes that can afford to
+// forget their authorizations when they stop
+type MemoryAuthorizationStore struct {
+	mutex          sync.Mutex
+	authorizations map[string]Authorization
+}
+
+// NewMemoryAuthorizationStore returns an empty MemoryAuthorizationStore
+func NewMemoryAuthorizationStore() *MemoryAuthorizationStore {
+	return &MemoryAuthorizationStore{authorizations: map[string]Authorization{}}
+}
+
+// Save stores a copy of the authorization
+func (s *MemoryAuthorizationStore) Save(a *Authorization) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.authorizations[a.OriginalID] = *a
+	return nil
+}
+
+// Delete removes the authorization
+func (s *MemoryAuthorizationStore) Delete(originalID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.authorizations, originalID)
+	return nil
+}
+
+// List returns copies of the stored authorizations
+func (s *MemoryAuthorizationStore) List() ([]*Authorization, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	authorizations := make([]*Authorization, 0, len(s.authorizations))
+	for _, a := range s.authorizations {
+		a := a
+		authorizations = append(authorizations, &a)
+	}
+	return authorizations, nil
+}
+
+// AuthorizationAction is what an AuthorizationPolicy decides to do with an authorization whose honor period ends
+type AuthorizationAction int
+
+// These constants are the actions an AuthorizationPolicy can take
+const (
+	AuthorizationKeep    AuthorizationAction = iota // Keep the authorization open, reauthorizing it when possible
+	AuthorizationCapture                            // Capture what remains of the authorization
+	AuthorizationVoid                               // Void what remains of the authorization
+)
+
+// AuthorizationPolicy decides what to do with an authorization whose honor period is about to end, such as
+// capturing it when its order shipped
+type AuthorizationPolicy func(a Authorization) AuthorizationAction
+
+// AuthorizationEventType is what happened to a watched authorization
+type AuthorizationEventType int
+
+// These constants are the types of the events of an AuthorizationWatcher
+const (
+	AuthorizationReauthorized AuthorizationEventType = iota // The authorization was reauthorized
+	AuthorizationCaptured                                   // What remained of the authorization was captured
+	AuthorizationVoided                                     // What remained of the authorization was voided
+	AuthorizationFailed                                     // An action failed, it is tried again on the next check
+	AuthorizationAtRisk                                     // The honor period ends and the authorization cannot be reauthorized anymore
+	AuthorizationExpired                                    // The authorization expired before it was captured or voided
+)
+
+func (t AuthorizationEventType) String() string {
+	switch t {
+	case AuthorizationReauthorized:
+		return "reauthorized"
+	case AuthorizationCaptured:
+		return "captured"
+	case AuthorizationVoided:
+		return "voided"
+	case AuthorizationFailed:
+		return "failed"
+	case AuthorizationAtRisk:
+		return "at risk"
+	default:
+		return "expired"
+	}
+}
+
+// AuthorizationEvent reports an action of an AuthorizationWatcher, or an authorization that needs attention.
+// Authorization is the state of the authorization after the action. Err is set on AuthorizationFailed events, and on
+// the events of actions that were taken but whose response could not be parsed (a *ResponseParseError) or that could
+// not be saved to the store
+type AuthorizationEvent struct {
+	Type          AuthorizationEventType
+	Authorization Authorization
+	Err           error
+	Time          time.Time
+}
+
+// AuthorizationWatcher keeps the authorizations of its Store from lapsing. Once the honor period of an
+// authorization is less than Margin away from its end, Policy decides whether to capture it, void it or keep it.
+// Kept authorizations are reauthorized on the first check after their honor period ended, as PayPal rejects
+// reauthorizations within it. Authorizations that cannot be reauthorized anymore are reported as
+// AuthorizationAtRisk once, and those that expire as AuthorizationExpired before being removed from the Store.
+// Interval is the time between two checks when the watcher runs in the background
+type AuthorizationWatcher struct {
+	Client   *PayPalClient
+	Store    AuthorizationStore
+	Policy   AuthorizationPolicy
+	Interval time.Duration
+	Margin   time.Duration
+
+	mutex  sync.Mutex
+	atRisk map[string]bool
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// These constants are the default Interval and Margin of an AuthorizationWatcher
+const (
+	DefaultWatchInterval = time.Hour
+	DefaultWatchMargin   = 12 * time.Hour
+)
+
+// NewAuthorizationWatcher returns a watcher of the authorizations of store, deciding what to do with them with policy.
+// A nil policy keeps every authorization
+func NewAuthorizationWatcher(client *PayPalClient, store AuthorizationStore, policy AuthorizationPolicy) *AuthorizationWatcher {
+	return &AuthorizationWatcher{
+		Client:   client,
+		Store:    store,
+		Policy:   policy,
+		Interval: DefaultWatchInterval,
+		Margin:   DefaultWatchMargin,
+		atRisk:   map[string]bool{},
+	}
+}
+
+// Watch adds the authorization to the store
+func (w *AuthorizationWatcher) Watch(a *Authorization) error {
+	return w.Store.Save(a)
+}
+
+// Check goes once through the authorizations of the store and returns the events of the actions taken
+func (w *AuthorizationWatcher) Check(now time.Time) ([]AuthorizationEvent, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.atRisk == nil {
+		w.atRisk = map[string]bool{}
+	}
+
+	authorizations, err := w.Store.List()
+	if err != nil {
+		return nil, err
+	}
+	var events []AuthorizationEvent
+	for _, a := range authorizations {
+		if event, ok := w.check(a, now); ok {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// check takes the action due on the authorization, if any. Every decision and record is made at now, never at the
+// time of the clock
+func (w *AuthorizationWatcher) check(a *Authorization, now time.Time) (AuthorizationEvent, bool) {
+	event := AuthorizationEvent{Time: now}
+	if !a.IsOpen(now) {
+		delete(w.atRisk, a.OriginalID)
+		if err := w.Store.Delete(a.OriginalID); err != nil {
+			event.Type, event.Authorization, event.Err = AuthorizationFailed, *a, err
+			return event, true
+		}
+		if a.IsExpired(now) && !a.Completed && !a.Voided {
+			event.Type, event.Authorization = AuthorizationExpired, *a
+			return event, true
+		}
+		return event, false
+	}
+
+	deadline := a.HonorPeriodEnd()
+	if a.ExpiresAt().Before(deadline) {
+		deadline = a.ExpiresAt()
+	}
+	if now.Before(deadline.Add(-w.Margin)) {
+		return event, false
+	}
+
+	action := AuthorizationKeep
+	if w.Policy != nil {
+		action = w.Policy(*a)
+	}
+	var err error
+	switch {
+	case action == AuthorizationCapture:
+		event.Type = AuthorizationCaptured
+		_, err = w.Client.captureAuthorization(a, "", true, "", now)
+	case action == AuthorizationVoid:
+		event.Type = AuthorizationVoided
+		_, err = w.Client.voidAuthorization(a, "", "", now)
+	case a.CanReauthorize(now):
+		event.Type = AuthorizationReauthorized
+		_, err = w.Client.reauthorizeAuthorization(a, "", now)
+	case !a.Reauthorized && a.IsHonored(now) && a.HonorPeriodEnd().Before(a.ExpiresAt()):
+		// It is reauthorized once the honor period ended
+		return event, false
+	default:
+		if w.atRisk[a.OriginalID] {
+			return event, false
+		}
+		w.atRisk[a.OriginalID] = true
+		event.Type, event.Authorization = AuthorizationAtRisk, *a
+		return event, true
+	}
+	if requestFailed(err) {
+		event.Type, event.Authorization, event.Err = AuthorizationFailed, *a, err
+		return event, true
+	}
+
+	event.Authorization, event.Err = *a, err
+	if a.IsOpen(now) {
+		err = w.Store.Save(a)
+	} else {
+		delete(w.atRisk, a.OriginalID)
+		err = w.Store.Delete(a.OriginalID)
+	}
+	if err != nil {
+		// The action was taken, but the store does not know it: report it so it is not taken twice
+		event.Err = err
+	}
+	return event, true
+}
+
+// Start checks the authorizations every Interval in the background, starting right away, until Stop is called.
+// The events of every check are sent on the returned channel, which is closed once the watcher stopped. The channel
+// has to be read for the checks to go on. Failures to list the authorizations are sent as AuthorizationFailed events
+func (w *AuthorizationWatcher) Start() <-chan AuthorizationEvent {
+	events := make(chan AuthorizationEvent)
+	w.stop, w.done = make(chan struct{}), make(chan struct{})
+	interval := w.Interval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	go func(stop, done chan struct{}) {
+		defer close(done)
+		defer close(events)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			now := time.Now()
+			checked, err := w.Check(now)
+			if err != nil {
+				checked = []AuthorizationEvent{{Type: AuthorizationFailed, Err: err, Time: now}}
+			}
+			for _, event := range checked {
+				select {
+				case events <- event:
+				case <-stop:
+					return
+				}
+			}
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+		}
+	}(w.stop, w.done)
+	return events
+}
+
+// Stop stops the background checks started by Start and waits for the one in progress to end
+func (w *AuthorizationWatcher) Stop() {
+	if w.stop == nil {
+		return
+	}
+	close(w.stop)
+	<-w.done
+	w.stop, w.done = nil, nil
+}
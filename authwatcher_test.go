@@ -0,0 +1,155 @@
+package paypal_test
+
+import (
+	"net/url"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/japhy-team/paypal"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorizationWatcherCheck(t *testing.T) {
+	now := time.Now()
+	authorizedAt := map[string]time.Time{}
+	var mutex sync.Mutex
+	var requests []url.Values
+	client := newTestClient(t, func(r url.Values) url.Values {
+		mutex.Lock()
+		defer mutex.Unlock()
+		requests = append(requests, r)
+		switch {
+		case r.Get("METHOD") == "DoReauthorization" && time.Since(authorizedAt[r.Get("AUTHORIZATIONID")]) < paypal.HonorPeriod:
+			return url.Values{"ACK": {"Failure"}, "L_ERRORCODE0": {"10617"}, "L_SHORTMESSAGE0": {"Reauthorization not allowed inside honor period"}}
+		case r.Get("METHOD") == "DoReauthorization" && r.Get("AUTHORIZATIONID") == "FAIL":
+			return url.Values{"ACK": {"Failure"}, "L_ERRORCODE0": {"10610"}, "L_SHORTMESSAGE0": {"Amount specified exceeds allowable limit"}}
+		case r.Get("METHOD") == "DoReauthorization":
+			return url.Values{"ACK": {"Success"}, "AUTHORIZATIONID": {"RE" + r.Get("AUTHORIZATIONID")}}
+		default:
+			return url.Values{"ACK": {"Success"}}
+		}
+	})
+
+	store := paypal.NewMemoryAuthorizationStore()
+	watcher := paypal.NewAuthorizationWatcher(client, store, func(a paypal.Authorization) paypal.AuthorizationAction {
+		switch a.OriginalID {
+		case "SHIPPED":
+			return paypal.AuthorizationCapture
+		case "CANCELLED":
+			return paypal.AuthorizationVoid
+		default:
+			return paypal.AuthorizationKeep
+		}
+	})
+	watch := func(id string, age time.Duration) {
+		mutex.Lock()
+		authorizedAt[id] = now.Add(-age)
+		mutex.Unlock()
+		assert.NoError(t, watcher.Watch(paypal.NewAuthorization(id, "30.00", "USD", now.Add(-age))))
+	}
+	// Within the margin of the end of their honor period, which HONORED has to wait for to be reauthorized
+	for _, id := range []string{"HONORED", "SHIPPED", "CANCELLED"} {
+		watch(id, 70*time.Hour)
+	}
+	for _, id := range []string{"KEPT", "FAIL"} {
+		watch(id, 73*time.Hour)
+	}
+	assert.NoError(t, watcher.Watch(paypal.NewAuthorization("RECENT", "30.00", "USD", now.Add(-10*time.Hour))))
+	assert.NoError(t, watcher.Watch(paypal.NewAuthorization("EXPIRED", "30.00", "USD", now.Add(-30*24*time.Hour))))
+	reauthorized := paypal.NewAuthorization("REAUTHORIZED", "30.00", "USD", now.Add(-10*24*time.Hour))
+	reauthorized.RecordReauthorization(&paypal.AuthorizationResult{AuthorizationID: "REAUTH", Timestamp: now.Add(-70 * time.Hour)})
+	assert.NoError(t, watcher.Watch(reauthorized))
+
+	events, err := watcher.Check(now)
+	assert.NoError(t, err)
+	types := map[string]paypal.AuthorizationEventType{}
+	for _, event := range events {
+		types[event.Authorization.OriginalID] = event.Type
+	}
+	assert.Equal(t, map[string]paypal.AuthorizationEventType{
+		"KEPT":         paypal.AuthorizationReauthorized,
+		"SHIPPED":      paypal.AuthorizationCaptured,
+		"CANCELLED":    paypal.AuthorizationVoided,
+		"FAIL":         paypal.AuthorizationFailed,
+		"EXPIRED":      paypal.AuthorizationExpired,
+		"REAUTHORIZED": paypal.AuthorizationAtRisk,
+	}, types)
+	assert.Len(t, requests, 4)
+
+	authorizations, err := store.List()
+	assert.NoError(t, err)
+	var ids []string
+	for _, a := range authorizations {
+		ids = append(ids, a.ID)
+	}
+	sort.Strings(ids)
+	assert.Equal(t, []string{"FAIL", "HONORED", "REAUTH", "RECENT", "REKEPT"}, ids)
+
+	// Only the failed reauthorization is tried again, the authorization at risk is reported once
+	events, err = watcher.Check(now)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, paypal.AuthorizationFailed, events[0].Type)
+		assert.Error(t, events[0].Err)
+	}
+}
+
+func TestAuthorizationWatcherStart(t *testing.T) {
+	client := newTestClient(t, func(r url.Values) url.Values {
+		return url.Values{"ACK": {"Success"}, "AUTHORIZATIONID": {"AUTH2"}}
+	})
+	watcher := paypal.NewAuthorizationWatcher(client, paypal.NewMemoryAuthorizationStore(), nil)
+	watcher.Interval = time.Millisecond
+	assert.NoError(t, watcher.Watch(paypal.NewAuthorization("AUTH1", "30.00", "USD", time.Now().Add(-73*time.Hour))))
+
+	events := watcher.Start()
+	event := <-events
+	assert.Equal(t, paypal.AuthorizationReauthorized, event.Type)
+	assert.Equal(t, "AUTH2", event.Authorization.ID)
+	watcher.Stop()
+	_, open := <-events
+	assert.False(t, open)
+}
+
+func TestAuthorizationWatcherCheckIsMadeAtNow(t *testing.T) {
+	var methods []string
+	client := newTestClient(t, func(r url.Values) url.Values {
+		methods = append(methods, r.Get("METHOD"))
+		if r.Get("METHOD") == "DoReauthorization" {
+			return url.Values{"ACK": {"Success"}, "AUTHORIZATIONID": {"REKEPT"}}
+		}
+		return url.Values{"ACK": {"Success"}, "TIMESTAMP": {"not a date"}}
+	})
+	store := paypal.NewMemoryAuthorizationStore()
+	watcher := paypal.NewAuthorizationWatcher(client, store, func(a paypal.Authorization) paypal.AuthorizationAction {
+		if a.OriginalID == "SHIPPED" {
+			return paypal.AuthorizationCapture
+		}
+		return paypal.AuthorizationKeep
+	})
+	now := time.Now()
+	// Still within its honor period by the clock, but not at the time of the check
+	assert.NoError(t, watcher.Watch(paypal.NewAuthorization("KEPT", "30.00", "USD", now.Add(-70*time.Hour))))
+	// Already expired by the clock, but not at the time of the check
+	assert.NoError(t, watcher.Watch(paypal.NewAuthorization("SHIPPED", "30.00", "USD", now.Add(-29*24*time.Hour-time.Hour))))
+
+	events, err := watcher.Check(now.Add(-2 * time.Hour))
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		// The capture went through even though its timestamp could not be parsed
+		assert.Equal(t, paypal.AuthorizationCaptured, events[0].Type)
+		assert.IsType(t, &paypal.ResponseParseError{}, events[0].Err)
+		assert.True(t, events[0].Authorization.Completed)
+	}
+
+	events, err = watcher.Check(now.Add(3 * time.Hour))
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, paypal.AuthorizationReauthorized, events[0].Type)
+		assert.Equal(t, now.Add(3*time.Hour).UTC(), events[0].Authorization.HonorPeriodStart)
+	}
+	assert.Equal(t, []string{"DoCapture", "DoReauthorization"}, methods)
+}
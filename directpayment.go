@@ -0,0 +1,303 @@
+package paypal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/japhy-team/paypal/internal/mask"
+)
+
+// PaymentAction is how a payment is settled
+type PaymentAction string
+
+// These constants are the payment actions of DoDirectPayment
+const (
+	PaymentActionSale          PaymentAction = "Sale"
+	PaymentActionAuthorization PaymentAction = "Authorization"
+)
+
+// CardType is the brand of a credit card (CREDITCARDTYPE)
+type CardType string
+
+// These constants are the card brands DoDirectPayment accepts
+const (
+	CardTypeVisa       CardType = "Visa"
+	CardTypeMasterCard CardType = "MasterCard"
+	CardTypeDiscover   CardType = "Discover"
+	CardTypeAmex       CardType = "Amex"
+	CardTypeMaestro    CardType = "Maestro"
+)
+
+// sensitiveParameters are the request parameters that carry card data or credentials, redacted by RedactValues
+var sensitiveParameters = map[string]bool{"ACCT": true, "EXPDATE": true, "CVV2": true, "PWD": true, "SIGNATURE": true}
+
+// CreditCard is the card of a DoDirectPayment. ExpDate is in the MMYYYY format.
+// Printing the card with any fmt verb or serializing it to JSON masks its number, expiration date and CVV2,
+// so that it can be logged
+type CreditCard struct {
+	Type      CardType
+	Number    string
+	ExpDate   string
+	CVV2      string
+	FirstName string
+	LastName  string
+}
+
+// maskedCreditCard mirrors CreditCard without any of its methods so the fmt and json packages fall back to their
+// default behaviour when printing an already masked copy
+type maskedCreditCard CreditCard
+
+// MaskCardNumber returns the card number with everything but the first 6 and the last 4 digits replaced by '*'.
+// Card numbers too short to keep both ends visible are masked entirely
+func MaskCardNumber(number string) string {
+	return mask.PAN(number)
+}
+
+// masked returns a copy of the card that is safe to print or serialize
+func (c CreditCard) masked() maskedCreditCard {
+	m := maskedCreditCard(c)
+	m.Number = mask.PAN(c.Number)
+	m.ExpDate = mask.All(c.ExpDate)
+	m.CVV2 = mask.All(c.CVV2)
+	return m
+}
+
+// String returns the card with its number masked
+func (c CreditCard) String() string {
+	return mask.String(c.masked())
+}
+
+// GoString returns the Go syntax representation of the card with its number masked. It is used by the %#v verb
+func (c CreditCard) GoString() string {
+	return mask.GoString(c.masked(), "paypal.CreditCard")
+}
+
+// Format implements fmt.Formatter so that no verb can print the full card number
+func (c CreditCard) Format(f fmt.State, verb rune) {
+	mask.Format(f, verb, c.masked(), "paypal.CreditCard")
+}
+
+// MarshalJSON serializes the card with its number masked
+func (c CreditCard) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.masked())
+}
+
+// Wipe clears the sensitive card data once it is no longer needed, typically right after the payment was sent.
+// Go strings are immutable, so Wipe drops the card's references to that data rather than overwriting memory
+// that may still be shared with the caller
+func (c *CreditCard) Wipe() {
+	c.Number = ""
+	c.ExpDate = ""
+	c.CVV2 = ""
+}
+
+// validate checks the card data. The errors never include the card data itself so they are safe to log
+func (c CreditCard) validate() error {
+	switch c.Type {
+	case CardTypeVisa, CardTypeMasterCard, CardTypeDiscover, CardTypeAmex, CardTypeMaestro:
+	default:
+		return newValidationError("unknown card type " + string(c.Type))
+	}
+	if len(c.Number) < 12 || len(c.Number) > 19 || !isDigits(c.Number) {
+		return newValidationError("the card number is not a card number")
+	}
+	if len(c.ExpDate) != 6 || !isDigits(c.ExpDate) {
+		return newValidationError("the card expiration date is not in the MMYYYY format")
+	}
+	if month, _ := strconv.Atoi(c.ExpDate[:2]); month < 1 || month > 12 {
+		return newValidationError("the card expiration date is not in the MMYYYY format")
+	}
+	if len(c.CVV2) != 0 && (len(c.CVV2) < 3 || len(c.CVV2) > 4 || !isDigits(c.CVV2)) {
+		return newValidationError("the CVV2 is not 3 or 4 digits")
+	}
+	return nil
+}
+
+// isDigits reports whether s is made of decimal digits only
+func isDigits(s string) bool {
+	return strings.Trim(s, "0123456789") == ""
+}
+
+// BillingAddress is the billing address of a card
+type BillingAddress struct {
+	Street      string `json:"street,omitempty"`
+	Street2     string `json:"street2,omitempty"`
+	City        string `json:"city,omitempty"`
+	State       string `json:"state,omitempty"`
+	Zip         string `json:"zip,omitempty"`
+	CountryCode string `json:"countrycode,omitempty"`
+}
+
+// DirectPaymentRequest holds the fields of a DoDirectPayment request. Action defaults to PaymentActionSale.
+// IPAddress is the IP address of the buyer, which PayPal requires for its fraud filters
+type DirectPaymentRequest struct {
+	Action       PaymentAction
+	Amount       string
+	CurrencyCode string
+	Card         CreditCard
+	Email        string
+	BillTo       BillingAddress
+	ShipTo       *ShippingAddress
+	IPAddress    string
+	InvoiceID    string
+	Description  string
+}
+
+// FraudFilter is a Fraud Management Filter that a payment triggered
+type FraudFilter struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// FraudFilters are the Fraud Management Filters a payment triggered, by the action they took: accept the payment,
+// hold it for review (pending), only report it, or deny it
+type FraudFilters struct {
+	Accepted []FraudFilter `json:"accepted,omitempty"`
+	Pending  []FraudFilter `json:"pending,omitempty"`
+	Reported []FraudFilter `json:"reported,omitempty"`
+	Denied   []FraudFilter `json:"denied,omitempty"`
+}
+
+// DirectPaymentResult is the typed response of DoDirectPayment. AVSCode is the result of the address verification
+// and CVV2Match the one of the CVV2 check, as returned by the processor
+type DirectPaymentResult struct {
+	TransactionID string       `json:"transactionid,omitempty"`
+	Amount        string       `json:"amt,omitempty"`
+	CurrencyCode  string       `json:"currencycode,omitempty"`
+	AVSCode       string       `json:"avscode,omitempty"`
+	CVV2Match     string       `json:"cvv2match,omitempty"`
+	FraudFilters  FraudFilters `json:"fraudfilters"`
+	CorrelationID string       `json:"correlationid,omitempty"`
+	Timestamp     time.Time    `json:"timestamp"`
+}
+
+// validate checks the payment before it is sent to PayPal
+func (r DirectPaymentRequest) validate() error {
+	switch r.Action {
+	case "", PaymentActionSale, PaymentActionAuthorization:
+	default:
+		return newValidationError("unknown payment action " + string(r.Action))
+	}
+	if _, err := parseAmount(r.Amount, r.CurrencyCode); err != nil {
+		return err
+	}
+	if len(r.IPAddress) == 0 {
+		return newValidationError("IPAddress is required")
+	}
+	return r.Card.validate()
+}
+
+// values returns the request values of the payment
+func (r DirectPaymentRequest) values() url.Values {
+	values := url.Values{}
+	values.Set("METHOD", "DoDirectPayment")
+	action := r.Action
+	if len(action) == 0 {
+		action = PaymentActionSale
+	}
+	values.Set("PAYMENTACTION", string(action))
+	values.Set("AMT", r.Amount)
+	values.Set("IPADDRESS", r.IPAddress)
+
+	setIfPresent := func(key, value string) {
+		if len(value) != 0 {
+			values.Set(key, value)
+		}
+	}
+	setIfPresent("CURRENCYCODE", r.CurrencyCode)
+	setIfPresent("INVNUM", r.InvoiceID)
+	setIfPresent("DESC", r.Description)
+	setIfPresent("EMAIL", r.Email)
+
+	values.Set("CREDITCARDTYPE", string(r.Card.Type))
+	values.Set("ACCT", r.Card.Number)
+	values.Set("EXPDATE", r.Card.ExpDate)
+	setIfPresent("CVV2", r.Card.CVV2)
+	setIfPresent("FIRSTNAME", r.Card.FirstName)
+	setIfPresent("LASTNAME", r.Card.LastName)
+
+	setIfPresent("STREET", r.BillTo.Street)
+	setIfPresent("STREET2", r.BillTo.Street2)
+	setIfPresent("CITY", r.BillTo.City)
+	setIfPresent("STATE", r.BillTo.State)
+	setIfPresent("ZIP", r.BillTo.Zip)
+	setIfPresent("COUNTRYCODE", r.BillTo.CountryCode)
+	if r.ShipTo != nil {
+		r.ShipTo.apply(values)
+	}
+	return values
+}
+
+// RedactValues returns a copy of request values that is safe to log, with the card number masked and the expiration
+// date, the CVV2 and the API credentials replaced by '*'
+func RedactValues(values url.Values) url.Values {
+	redacted := url.Values{}
+	for key, value := range values {
+		if !sensitiveParameters[key] {
+			redacted[key] = append([]string(nil), value...)
+			continue
+		}
+		for _, v := range value {
+			if key == "ACCT" {
+				redacted.Add(key, mask.PAN(v))
+			} else {
+				redacted.Add(key, mask.All(v))
+			}
+		}
+	}
+	return redacted
+}
+
+// DoDirectPayment charges or authorizes a card (Website Payments Pro).
+// When PayPal answered, the result is returned even along with a PayPalError, so that the AVS and CVV2 results and
+// the Fraud Management Filters of a declined payment can be looked at.
+// The card data is dropped from the request values once sent, and never appears in the errors
+// See https://developer.paypal.com/docs/classic/api/merchant/DoDirectPayment-API-Operation-NVP/ for details
+func (pClient *PayPalClient) DoDirectPayment(r DirectPaymentRequest) (*DirectPaymentResult, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+	values := r.values()
+	response, err := pClient.PerformRequest(values)
+	for key := range sensitiveParameters {
+		values.Del(key)
+	}
+	if response == nil {
+		return nil, err
+	}
+
+	result := &DirectPaymentResult{
+		TransactionID: response.Values.Get("TRANSACTIONID"),
+		Amount:        response.Values.Get("AMT"),
+		CurrencyCode:  response.Values.Get("CURRENCYCODE"),
+		AVSCode:       response.Values.Get("AVSCODE"),
+		CVV2Match:     response.Values.Get("CVV2MATCH"),
+		CorrelationID: response.CorrelationID,
+		Timestamp:     response.Timestamp,
+		FraudFilters: FraudFilters{
+			Accepted: parseFraudFilters(response.Values, "ACCEPT"),
+			Pending:  parseFraudFilters(response.Values, "PENDING"),
+			Reported: parseFraudFilters(response.Values, "REPORT"),
+			Denied:   parseFraudFilters(response.Values, "DENY"),
+		},
+	}
+	return result, err
+}
+
+// parseFraudFilters reads the Fraud Management Filters of a category, sent as L_FMF<category>IDn and
+// L_FMF<category>NAMEn
+func parseFraudFilters(values url.Values, category string) []FraudFilter {
+	var filters []FraudFilter
+	for i := 0; ; i++ {
+		n := strconv.Itoa(i)
+		id, ok := values["L_FMF"+category+"ID"+n]
+		if !ok {
+			return filters
+		}
+		filters = append(filters, FraudFilter{ID: id[0], Name: values.Get("L_FMF" + category + "NAME" + n)})
+	}
+}
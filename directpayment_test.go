@@ -0,0 +1,201 @@
+package paypal_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/japhy-team/paypal"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testDirectPayment() paypal.DirectPaymentRequest {
+	return paypal.DirectPaymentRequest{
+		Action:       paypal.PaymentActionAuthorization,
+		Amount:       "25.00",
+		CurrencyCode: "USD",
+		Card: paypal.CreditCard{
+			Type:      paypal.CardTypeVisa,
+			Number:    "4111111111111111",
+			ExpDate:   "122030",
+			CVV2:      "123",
+			FirstName: "John",
+			LastName:  "Doe",
+		},
+		BillTo: paypal.BillingAddress{
+			Street:      "1 Main St",
+			City:        "San Jose",
+			State:       "CA",
+			Zip:         "95131",
+			CountryCode: "US",
+		},
+		IPAddress: "203.0.113.7",
+		InvoiceID: "ORDER-42",
+	}
+}
+
+func TestDoDirectPayment(t *testing.T) {
+	var request url.Values
+	client := newTestClient(t, func(r url.Values) url.Values {
+		request = r
+		return url.Values{
+			"ACK":               {"SuccessWithWarning"},
+			"TIMESTAMP":         {"2020-03-01T10:00:00Z"},
+			"CORRELATIONID":     {"abc123"},
+			"TRANSACTIONID":     {"TX1"},
+			"AMT":               {"25.00"},
+			"CURRENCYCODE":      {"USD"},
+			"AVSCODE":           {"X"},
+			"CVV2MATCH":         {"M"},
+			"L_FMFREPORTID0":    {"1"},
+			"L_FMFREPORTNAME0":  {"AVS No Match"},
+			"L_FMFPENDINGID0":   {"12"},
+			"L_FMFPENDINGNAME0": {"Large Order Number"},
+			"L_FMFPENDINGID1":   {"13"},
+			"L_FMFPENDINGNAME1": {"Unconfirmed Address"},
+			"L_SHORTMESSAGE0":   {"Transaction Pending"},
+			"L_SEVERITYCODE0":   {"Warning"},
+			"L_LONGMESSAGE0":    {"Payment pending review by Fraud Management Filters"},
+		}
+	})
+
+	result, err := client.DoDirectPayment(testDirectPayment())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "DoDirectPayment", request.Get("METHOD"))
+	assert.Equal(t, "Authorization", request.Get("PAYMENTACTION"))
+	assert.Equal(t, "25.00", request.Get("AMT"))
+	assert.Equal(t, "203.0.113.7", request.Get("IPADDRESS"))
+	assert.Equal(t, "Visa", request.Get("CREDITCARDTYPE"))
+	assert.Equal(t, "4111111111111111", request.Get("ACCT"))
+	assert.Equal(t, "122030", request.Get("EXPDATE"))
+	assert.Equal(t, "123", request.Get("CVV2"))
+	assert.Equal(t, "1 Main St", request.Get("STREET"))
+	assert.Equal(t, "US", request.Get("COUNTRYCODE"))
+	assert.Equal(t, "ORDER-42", request.Get("INVNUM"))
+
+	assert.Equal(t, "TX1", result.TransactionID)
+	assert.Equal(t, "X", result.AVSCode)
+	assert.Equal(t, "M", result.CVV2Match)
+	assert.Equal(t, "abc123", result.CorrelationID)
+	assert.Equal(t, 2020, result.Timestamp.Year())
+	assert.Equal(t, []paypal.FraudFilter{{ID: "1", Name: "AVS No Match"}}, result.FraudFilters.Reported)
+	assert.Equal(t, []paypal.FraudFilter{
+		{ID: "12", Name: "Large Order Number"},
+		{ID: "13", Name: "Unconfirmed Address"},
+	}, result.FraudFilters.Pending)
+	assert.Empty(t, result.FraudFilters.Denied)
+}
+
+func TestDoDirectPaymentDefaultsToSale(t *testing.T) {
+	var request url.Values
+	client := newTestClient(t, func(r url.Values) url.Values {
+		request = r
+		return url.Values{"ACK": {"Success"}, "TRANSACTIONID": {"TX2"}}
+	})
+
+	payment := testDirectPayment()
+	payment.Action = ""
+	_, err := client.DoDirectPayment(payment)
+	assert.NoError(t, err)
+	assert.Equal(t, "Sale", request.Get("PAYMENTACTION"))
+}
+
+func TestDoDirectPaymentDenied(t *testing.T) {
+	client := newTestClient(t, func(r url.Values) url.Values {
+		return url.Values{
+			"ACK":             {"Failure"},
+			"AVSCODE":         {"N"},
+			"CVV2MATCH":       {"N"},
+			"L_FMFDENYID0":    {"2"},
+			"L_FMFDENYNAME0":  {"CVV2 No Match"},
+			"L_ERRORCODE0":    {"11611"},
+			"L_SHORTMESSAGE0": {"Transaction refused because of fraud filters"},
+			"L_SEVERITYCODE0": {"Error"},
+		}
+	})
+
+	result, err := client.DoDirectPayment(testDirectPayment())
+	pError, ok := err.(*paypal.PayPalError)
+	if assert.True(t, ok) {
+		assert.Equal(t, "11611", pError.ErrorCode)
+	}
+	if assert.NotNil(t, result) {
+		assert.Equal(t, "N", result.AVSCode)
+		assert.Equal(t, "N", result.CVV2Match)
+		assert.Equal(t, []paypal.FraudFilter{{ID: "2", Name: "CVV2 No Match"}}, result.FraudFilters.Denied)
+	}
+	assert.NotContains(t, err.Error(), "4111111111111111")
+}
+
+func TestDoDirectPaymentValidation(t *testing.T) {
+	client := newTestClient(t, func(r url.Values) url.Values {
+		t.Error("An invalid payment was sent")
+		return url.Values{"ACK": {"Success"}}
+	})
+
+	tests := map[string]func(*paypal.DirectPaymentRequest){
+		"action":      func(r *paypal.DirectPaymentRequest) { r.Action = "Order" },
+		"amount":      func(r *paypal.DirectPaymentRequest) { r.Amount = "25.001" },
+		"ip address":  func(r *paypal.DirectPaymentRequest) { r.IPAddress = "" },
+		"card type":   func(r *paypal.DirectPaymentRequest) { r.Card.Type = "Diners" },
+		"card number": func(r *paypal.DirectPaymentRequest) { r.Card.Number = "4111-1111-1111-1111" },
+		"exp date":    func(r *paypal.DirectPaymentRequest) { r.Card.ExpDate = "132030" },
+		"cvv2":        func(r *paypal.DirectPaymentRequest) { r.Card.CVV2 = "12" },
+	}
+	for name, change := range tests {
+		payment := testDirectPayment()
+		change(&payment)
+		_, err := client.DoDirectPayment(payment)
+		if assert.IsType(t, &paypal.ValidationError{}, err, name) {
+			assert.NotContains(t, err.Error(), "4111", name)
+		}
+	}
+}
+
+func TestCreditCardIsMasked(t *testing.T) {
+	payment := testDirectPayment()
+	for _, format := range []string{"%v", "%+v", "%#v", "%s", "%q", "%d"} {
+		for _, printed := range []string{fmt.Sprintf(format, payment.Card), fmt.Sprintf(format, payment)} {
+			assert.NotContains(t, printed, "4111111111111111", format)
+			assert.NotContains(t, printed, "122030", format)
+			assert.NotContains(t, printed, "CVV2:123", format)
+		}
+	}
+	assert.Contains(t, payment.Card.String(), "411111******1111")
+	assert.Contains(t, payment.Card.GoString(), "paypal.CreditCard{")
+
+	data, err := json.Marshal(payment)
+	if assert.NoError(t, err) {
+		assert.NotContains(t, string(data), "4111111111111111")
+		assert.Contains(t, string(data), "411111******1111")
+	}
+
+	payment.Card.Wipe()
+	assert.Empty(t, payment.Card.Number)
+	assert.Empty(t, payment.Card.ExpDate)
+	assert.Empty(t, payment.Card.CVV2)
+}
+
+func TestRedactValues(t *testing.T) {
+	values := url.Values{
+		"METHOD":    {"DoDirectPayment"},
+		"ACCT":      {"4111111111111111"},
+		"EXPDATE":   {"122030"},
+		"CVV2":      {"123"},
+		"PWD":       {"password"},
+		"SIGNATURE": {"signature"},
+	}
+	redacted := paypal.RedactValues(values)
+	assert.Equal(t, "DoDirectPayment", redacted.Get("METHOD"))
+	assert.Equal(t, "411111******1111", redacted.Get("ACCT"))
+	assert.Equal(t, "******", redacted.Get("EXPDATE"))
+	assert.Equal(t, "***", redacted.Get("CVV2"))
+	assert.False(t, strings.Contains(redacted.Encode(), "password"))
+	assert.Equal(t, "4111111111111111", values.Get("ACCT"), "the values themselves are left alone")
+	assert.Equal(t, "****", paypal.MaskCardNumber("4111"))
+}
@@ -0,0 +1,54 @@
+// Package amount converts the decimal amounts of the PayPal APIs to and from the minor unit of their currency, so that
+// amounts can be added and compared exactly. It is shared by the paypal and payflow packages, which each know the
+// currencies of their API.
+package amount
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Parse converts a decimal amount such as "10.50" into the minor unit of a currency with the given number of decimals,
+// such as 1050 cents. It reports false when amount is not a positive decimal number with at most that many decimals
+func Parse(amount string, decimals int) (int64, bool) {
+	whole, fraction := amount, ""
+	if i := strings.IndexByte(amount, '.'); i >= 0 {
+		whole, fraction = amount[:i], amount[i+1:]
+	}
+	if len(whole) == 0 || len(fraction) > decimals || strings.ContainsAny(whole+fraction, "+-") {
+		return 0, false
+	}
+	fraction += strings.Repeat("0", decimals-len(fraction))
+
+	units, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return units, true
+}
+
+// Format converts an amount in the minor unit of a currency with the given number of decimals back into a decimal
+// amount
+func Format(units int64, decimals int) string {
+	if decimals == 0 {
+		return strconv.FormatInt(units, 10)
+	}
+	sign := ""
+	if units < 0 {
+		sign, units = "-", -units
+	}
+	scale := int64(1)
+	for i := 0; i < decimals; i++ {
+		scale *= 10
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, units/scale, decimals, units%scale)
+}
+
+// Invalid describes an amount Parse rejected, naming its currency unless it is the account default
+func Invalid(amount, currency string) string {
+	if len(currency) == 0 {
+		return fmt.Sprintf("invalid amount %q", amount)
+	}
+	return fmt.Sprintf("invalid %s amount %q", currency, amount)
+}
@@ -0,0 +1,38 @@
+package amount_test
+
+import (
+	"testing"
+
+	"github.com/japhy-team/paypal/internal/amount"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	for value, expected := range map[string]int64{"10": 1000, "10.5": 1050, "10.50": 1050, "0.01": 1, ".5": -1, "10.001": -1, "-1.00": -1, "+1": -1, "ten": -1, "": -1} {
+		units, ok := amount.Parse(value, 2)
+		if expected < 0 {
+			assert.False(t, ok, value)
+		} else if assert.True(t, ok, value) {
+			assert.Equal(t, expected, units, value)
+		}
+	}
+	units, ok := amount.Parse("1500", 0)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1500), units)
+	_, ok = amount.Parse("1500.5", 0)
+	assert.False(t, ok)
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "10.50", amount.Format(1050, 2))
+	assert.Equal(t, "0.05", amount.Format(5, 2))
+	assert.Equal(t, "-0.05", amount.Format(-5, 2))
+	assert.Equal(t, "1500", amount.Format(1500, 0))
+	assert.Equal(t, "1.500", amount.Format(1500, 3))
+}
+
+func TestInvalid(t *testing.T) {
+	assert.Equal(t, `invalid EUR amount "1.001"`, amount.Invalid("1.001", "EUR"))
+	assert.Equal(t, `invalid amount "1.001"`, amount.Invalid("1.001", ""))
+}
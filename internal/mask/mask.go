@@ -0,0 +1,62 @@
+// Package mask hides card and bank account data from logs. It is shared by the card and bank account types of the
+// paypal and payflow packages, which print and serialize a masked copy of themselves.
+package mask
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// All replaces every character of value by '*', keeping its length
+func All(value string) string {
+	return strings.Repeat("*", len(value))
+}
+
+// PAN returns the card number with everything but the first 6 and the last 4 digits replaced by '*'.
+// Card numbers too short to keep both ends visible are masked entirely
+func PAN(pan string) string {
+	if len(pan) <= 10 {
+		return All(pan)
+	}
+	return pan[:6] + strings.Repeat("*", len(pan)-10) + pan[len(pan)-4:]
+}
+
+// AccountNumber returns the bank account number with everything but the last 4 digits replaced by '*'.
+// Account numbers too short to keep them visible are masked entirely
+func AccountNumber(number string) string {
+	if len(number) <= 4 {
+		return All(number)
+	}
+	return strings.Repeat("*", len(number)-4) + number[len(number)-4:]
+}
+
+// String returns masked, an already masked copy of a value, with its field names
+func String(masked interface{}) string {
+	return fmt.Sprintf("%+v", masked)
+}
+
+// GoString returns the Go syntax representation of masked under the name of the type it was masked from, such as
+// "paypal.CreditCard"
+func GoString(masked interface{}, name string) string {
+	return strings.Replace(fmt.Sprintf("%#v", masked), fmt.Sprintf("%T", masked), name, 1)
+}
+
+// Format prints masked for any verb, so that a type implementing fmt.Formatter with it can never print its own
+// sensitive fields. name is the name of the type masked was masked from
+func Format(f fmt.State, verb rune, masked interface{}, name string) {
+	switch {
+	case verb == 'v' && f.Flag('#'):
+		io.WriteString(f, GoString(masked, name))
+	case verb == 'v' && f.Flag('+'):
+		fmt.Fprintf(f, "%+v", masked)
+	case verb == 'v':
+		fmt.Fprintf(f, "%v", masked)
+	case verb == 's':
+		io.WriteString(f, String(masked))
+	case verb == 'q':
+		fmt.Fprintf(f, "%q", String(masked))
+	default:
+		fmt.Fprintf(f, "%%!%c(%s=%s)", verb, name, String(masked))
+	}
+}
@@ -0,0 +1,42 @@
+package mask_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/japhy-team/paypal/internal/mask"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPAN(t *testing.T) {
+	assert.Equal(t, "411111******1111", mask.PAN("4111111111111111"))
+	assert.Equal(t, "422222***2222", mask.PAN("4222222222222"))
+	assert.Equal(t, "**********", mask.PAN("1234567890"))
+	assert.Equal(t, "", mask.PAN(""))
+	assert.Equal(t, "***", mask.All("123"))
+}
+
+func TestAccountNumber(t *testing.T) {
+	assert.Equal(t, "******7890", mask.AccountNumber("1234567890"))
+	assert.Equal(t, "****", mask.AccountNumber("1234"))
+	assert.Equal(t, "", mask.AccountNumber(""))
+}
+
+type maskedCard struct{ Number string }
+
+type card struct{ Number string }
+
+func (c card) Format(f fmt.State, verb rune) {
+	mask.Format(f, verb, maskedCard{mask.PAN(c.Number)}, "mask_test.card")
+}
+
+func TestFormat(t *testing.T) {
+	c := card{Number: "4111111111111111"}
+	for _, format := range []string{"%v", "%+v", "%#v", "%s", "%q", "%d", "%x"} {
+		assert.NotContains(t, fmt.Sprintf(format, c), "4111111111111111", format)
+	}
+	assert.Equal(t, "{Number:411111******1111}", fmt.Sprintf("%+v", c))
+	assert.Equal(t, `mask_test.card{Number:"411111******1111"}`, fmt.Sprintf("%#v", c))
+	assert.Equal(t, "%!d(mask_test.card={Number:411111******1111})", fmt.Sprintf("%d", c))
+}
@@ -0,0 +1,178 @@
+package payflow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/japhy-team/paypal/internal/mask"
+)
+
+// ACHAccountType is the type of bank account debited or credited by an ACH transaction
+type ACHAccountType string
+
+// These constants are the bank account types supported by ACH transactions
+const (
+	ACHChecking ACHAccountType = "C"
+	ACHSavings  ACHAccountType = "S"
+)
+
+// SECCode is the NACHA Standard Entry Class code that states how the account holder authorized the transaction
+type SECCode string
+
+// These constants are the Standard Entry Class codes supported by ACH transactions
+const (
+	SECWeb SECCode = "WEB" // Authorized over the internet
+	SECPPD SECCode = "PPD" // Prearranged payment or deposit authorized in writing by a consumer
+	SECCCD SECCode = "CCD" // Corporate credit or debit between businesses
+)
+
+// PayPalBankAccount is composed of the data required to conduct an ACH (electronic check) transaction against the payflow API.
+// Name is the name of the account holder as it appears on the account.
+// A prenote is a zero amount transaction that validates the account before it is debited or credited.
+// ACH transactions are always in US dollars, Amount is in USD.
+type PayPalBankAccount struct {
+	RoutingNumber string              `json:"routingNumber"`
+	AccountNumber string              `json:"accountNumber"`
+	AccountType   ACHAccountType      `json:"accountType"`
+	SECCode       SECCode             `json:"secCode"`
+	Name          string              `json:"name"`
+	Amount        string              `json:"amount"`
+	Prenote       bool                `json:"prenote"`
+	Metadata      TransactionMetadata `json:"metadata"`
+}
+
+// ACHValues are the response values specific to ACH transactions
+type ACHValues struct {
+	*PayPalValues
+	TraceID   string `json:"TRACEID,omitempty"` // Trace number the ACH network assigned to the entry
+	ACHStatus string `json:"ACHSTATUS,omitempty"`
+}
+
+// ValidRoutingNumber reports whether routingNumber is a 9 digit ABA routing transit number with a valid check digit
+func ValidRoutingNumber(routingNumber string) bool {
+	if len(routingNumber) != 9 {
+		return false
+	}
+	weights := [3]int{3, 7, 1}
+	sum := 0
+	for i, r := range routingNumber {
+		if r < '0' || r > '9' {
+			return false
+		}
+		sum += int(r-'0') * weights[i%3]
+	}
+	return sum%10 == 0
+}
+
+// Validate checks the bank account before it is sent to Payflow
+func (a PayPalBankAccount) Validate() error {
+	if !ValidRoutingNumber(a.RoutingNumber) {
+		return errors.New("payflow: invalid ABA routing number")
+	}
+	if len(a.AccountNumber) == 0 || len(a.AccountNumber) > 17 || strings.Trim(a.AccountNumber, "0123456789") != "" {
+		return errors.New("payflow: bank account numbers are 1 to 17 digits")
+	}
+	switch a.AccountType {
+	case ACHChecking, ACHSavings:
+	default:
+		return fmt.Errorf("payflow: unknown ACH account type %q", a.AccountType)
+	}
+	switch a.SECCode {
+	case SECWeb, SECPPD, SECCCD:
+	default:
+		return fmt.Errorf("payflow: unsupported SEC code %q", a.SECCode)
+	}
+	if len(a.Name) == 0 {
+		return errors.New("payflow: the account holder name is required for ACH transactions")
+	}
+	if !a.Prenote {
+		if _, err := parseAmount(a.Amount, CurrencyUSD); err != nil {
+			return err
+		}
+	}
+	return a.Metadata.Validate()
+}
+
+// masked returns a copy of the bank account that is safe to print
+func (a PayPalBankAccount) masked() maskedBankAccount {
+	m := maskedBankAccount(a)
+	m.AccountNumber = mask.AccountNumber(a.AccountNumber)
+	return m
+}
+
+// maskedBankAccount mirrors PayPalBankAccount without any of its methods, see maskedCreditCard
+type maskedBankAccount PayPalBankAccount
+
+// String returns the bank account with all but the last 4 digits of the account number masked
+func (a PayPalBankAccount) String() string {
+	return mask.String(a.masked())
+}
+
+// GoString returns the Go syntax representation of the bank account with its account number masked. It is used by
+// the %#v verb
+func (a PayPalBankAccount) GoString() string {
+	return mask.GoString(a.masked(), "payflow.PayPalBankAccount")
+}
+
+// Format implements fmt.Formatter so that no verb can print the full account number
+func (a PayPalBankAccount) Format(f fmt.State, verb rune) {
+	mask.Format(f, verb, a.masked(), "payflow.PayPalBankAccount")
+}
+
+// MarshalJSON serializes the bank account with all but the last 4 digits of the account number masked
+func (a PayPalBankAccount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.masked())
+}
+
+// achValues builds the request values shared by ACH transactions
+func achValues(trxType string, a PayPalBankAccount) url.Values {
+	values := url.Values{}
+	values.Set("TRXTYPE", trxType)
+	values.Set("TENDER", "A")
+	values.Set("ABA", a.RoutingNumber)
+	values.Set("ACCT", a.AccountNumber)
+	values.Set("ACCTTYPE", string(a.AccountType))
+	values.Set("AUTHTYPE", string(a.SECCode))
+	values.Set("FIRSTNAME", a.Name)
+	values.Set("AMT", a.Amount)
+	if a.Prenote {
+		values.Set("PRENOTE", "Y")
+		values.Set("AMT", "0.00")
+	}
+	a.Metadata.apply(values)
+	return values
+}
+
+func (pClient *PayPalClient) achTransact(trxType string, a PayPalBankAccount) (*ACHValues, error) {
+	if err := pClient.checkTender("A"); err != nil {
+		return nil, err
+	}
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+
+	res, err := pClient.transact(achValues(trxType, a))
+	if res == nil {
+		return nil, err
+	}
+	return &ACHValues{
+		PayPalValues: res,
+		TraceID:      res.takeExtra("TRACEID"),
+		ACHStatus:    res.takeExtra("ACHSTATUS"),
+	}, err
+}
+
+// DoACHSale debits the bank account for a.Amount (TENDER=A).
+// The account is validated, including the routing number check digit, before anything is sent
+func (pClient *PayPalClient) DoACHSale(a PayPalBankAccount) (*ACHValues, error) {
+	return pClient.achTransact("S", a)
+}
+
+// DoACHCredit credits the bank account with a.Amount (TENDER=A).
+// The account is validated, including the routing number check digit, before anything is sent
+func (pClient *PayPalClient) DoACHCredit(a PayPalBankAccount) (*ACHValues, error) {
+	return pClient.achTransact("C", a)
+}
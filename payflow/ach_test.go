@@ -0,0 +1,104 @@
+package payflow_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/japhy-team/paypal/payflow"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sampleBankAccount() payflow.PayPalBankAccount {
+	return payflow.PayPalBankAccount{
+		RoutingNumber: "011000015",
+		AccountNumber: "1234567890",
+		AccountType:   payflow.ACHChecking,
+		SECCode:       payflow.SECCCD,
+		Name:          "Acme Corp",
+		Amount:        "125.00",
+	}
+}
+
+func TestValidRoutingNumber(t *testing.T) {
+	assert.True(t, payflow.ValidRoutingNumber("011000015"))
+	assert.True(t, payflow.ValidRoutingNumber("121000358"))
+	assert.False(t, payflow.ValidRoutingNumber("011000016"))
+	assert.False(t, payflow.ValidRoutingNumber("01100001"))
+	assert.False(t, payflow.ValidRoutingNumber("01100001a"))
+}
+
+func TestDoACHSale(t *testing.T) {
+	var request url.Values
+	gateway := newTestGateway(t, func(r url.Values) url.Values {
+		request = r
+		return url.Values{"RESULT": {"0"}, "PNREF": {"V24A0A000001"}, "TRACEID": {"091000010000001"}}
+	})
+
+	response, err := gateway.DoACHSale(sampleBankAccount())
+	assert.NoError(t, err)
+	assert.Equal(t, "V24A0A000001", response.PNREF)
+	assert.Equal(t, "091000010000001", response.TraceID)
+	assert.Equal(t, "S", request.Get("TRXTYPE"))
+	assert.Equal(t, "A", request.Get("TENDER"))
+	assert.Equal(t, "011000015", request.Get("ABA"))
+	assert.Equal(t, "1234567890", request.Get("ACCT"))
+	assert.Equal(t, "C", request.Get("ACCTTYPE"))
+	assert.Equal(t, "CCD", request.Get("AUTHTYPE"))
+	assert.Equal(t, "125.00", request.Get("AMT"))
+}
+
+func TestDoACHCreditPrenote(t *testing.T) {
+	var request url.Values
+	gateway := newTestGateway(t, func(r url.Values) url.Values {
+		request = r
+		return url.Values{"RESULT": {"0"}}
+	})
+
+	account := sampleBankAccount()
+	account.Prenote = true
+	account.Amount = ""
+	_, err := gateway.DoACHCredit(account)
+	assert.NoError(t, err)
+	assert.Equal(t, "C", request.Get("TRXTYPE"))
+	assert.Equal(t, "Y", request.Get("PRENOTE"))
+	assert.Equal(t, "0.00", request.Get("AMT"))
+}
+
+func TestDoACHSaleRejectsInvalidAccounts(t *testing.T) {
+	gateway := newTestGateway(t, func(r url.Values) url.Values {
+		t.Errorf("invalid account was sent to the gateway: %v", r)
+		return url.Values{"RESULT": {"0"}}
+	})
+
+	account := sampleBankAccount()
+	account.RoutingNumber = "011000016"
+	_, err := gateway.DoACHSale(account)
+	assert.Error(t, err)
+
+	account = sampleBankAccount()
+	account.SECCode = "TEL"
+	_, err = gateway.DoACHSale(account)
+	assert.Error(t, err)
+}
+
+func TestBankAccountNeverPrintsAccountNumber(t *testing.T) {
+	account := sampleBankAccount()
+	for _, format := range []string{"%v", "%+v", "%#v", "%s", "%q", "%d", "%x"} {
+		printed := fmt.Sprintf(format, account)
+		assert.NotContains(t, printed, account.AccountNumber, format)
+		assert.Contains(t, printed, "******7890", format)
+	}
+}
+
+func TestBankAccountNeverSerializesAccountNumber(t *testing.T) {
+	account := sampleBankAccount()
+	data, err := json.Marshal(struct {
+		Account payflow.PayPalBankAccount `json:"account"`
+	}{account})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), account.AccountNumber)
+	assert.Contains(t, string(data), `"accountNumber":"******7890"`)
+}
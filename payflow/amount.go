@@ -0,0 +1,133 @@
+package payflow
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/japhy-team/paypal/internal/amount"
+)
+
+// Currency is the ISO 4217 code of the currency of a transaction (CURRENCY).
+// The empty Currency is the default currency of the merchant account, and no CURRENCY is sent
+type Currency string
+
+// These constants are the currencies Payflow transactions can be conducted in.
+// Which of them a merchant account accepts depends on its processor, see PayPalClient.SupportedCurrencies
+const (
+	CurrencyAUD Currency = "AUD"
+	CurrencyCAD Currency = "CAD"
+	CurrencyCHF Currency = "CHF"
+	CurrencyCZK Currency = "CZK"
+	CurrencyDKK Currency = "DKK"
+	CurrencyEUR Currency = "EUR"
+	CurrencyGBP Currency = "GBP"
+	CurrencyHKD Currency = "HKD"
+	CurrencyHUF Currency = "HUF"
+	CurrencyJPY Currency = "JPY"
+	CurrencyNOK Currency = "NOK"
+	CurrencyNZD Currency = "NZD"
+	CurrencyPLN Currency = "PLN"
+	CurrencySEK Currency = "SEK"
+	CurrencySGD Currency = "SGD"
+	CurrencyUSD Currency = "USD"
+)
+
+// MaxAmountLength is the longest amount Payflow accepts, decimal separator included
+const MaxAmountLength = 10
+
+// zeroDecimalCurrencies are the currencies whose amounts have no minor unit. Every other currency has 2 decimals
+var zeroDecimalCurrencies = map[Currency]bool{
+	CurrencyHUF: true,
+	CurrencyJPY: true,
+}
+
+// UnsupportedCurrencyError is returned when a transaction is asked for in a currency the merchant account does not
+// accept. Nothing is sent to Payflow
+type UnsupportedCurrencyError struct {
+	Currency  Currency
+	Supported []Currency
+}
+
+func (e *UnsupportedCurrencyError) Error() string {
+	supported := make([]string, len(e.Supported))
+	for i, currency := range e.Supported {
+		supported[i] = string(currency)
+	}
+	if len(supported) == 0 {
+		return fmt.Sprintf("payflow: unknown currency %q", e.Currency)
+	}
+	return fmt.Sprintf("payflow: the merchant account does not accept %s, only %s", e.Currency, strings.Join(supported, ", "))
+}
+
+// Decimals is the number of decimals amounts in the currency have
+func (c Currency) Decimals() int {
+	if zeroDecimalCurrencies[c] {
+		return 0
+	}
+	return 2
+}
+
+// ValidateAmount checks that amount is a valid amount in the currency: a positive decimal number with no more
+// decimals than the currency has and no longer than MaxAmountLength
+func (c Currency) ValidateAmount(amount string) error {
+	_, err := parseAmount(amount, c)
+	return err
+}
+
+// parseAmount converts a decimal amount such as "3.50" into the minor unit of the currency (cents for most of them)
+// so amounts can be added and compared exactly
+func parseAmount(value string, currency Currency) (int64, error) {
+	units, ok := amount.Parse(value, currency.Decimals())
+	if !ok {
+		return 0, errors.New("payflow: " + amount.Invalid(value, string(currency)))
+	}
+	if len(value) > MaxAmountLength {
+		return 0, fmt.Errorf("payflow: amount %q is longer than %d characters", value, MaxAmountLength)
+	}
+	return units, nil
+}
+
+// formatAmount converts an amount in the minor unit of the currency back into the decimal format Payflow expects
+func formatAmount(units int64, currency Currency) string {
+	return amount.Format(units, currency.Decimals())
+}
+
+// checkCurrency returns an UnsupportedCurrencyError when the merchant account does not accept the currency.
+// The empty Currency, the account default, is always accepted
+func (pClient *PayPalClient) checkCurrency(currency Currency) error {
+	if len(currency) == 0 {
+		return nil
+	}
+	if len(currency) != 3 || strings.ToUpper(string(currency)) != string(currency) {
+		return &UnsupportedCurrencyError{Currency: currency}
+	}
+	if len(pClient.SupportedCurrencies) == 0 {
+		return nil
+	}
+	for _, supported := range pClient.SupportedCurrencies {
+		if supported == currency {
+			return nil
+		}
+	}
+	return &UnsupportedCurrencyError{Currency: currency, Supported: pClient.SupportedCurrencies}
+}
+
+// validateAmount checks that the currency is accepted and that a non empty amount is valid in it
+func (pClient *PayPalClient) validateAmount(amount string, currency Currency) error {
+	if err := pClient.checkCurrency(currency); err != nil {
+		return err
+	}
+	if len(amount) == 0 {
+		return nil
+	}
+	return currency.ValidateAmount(amount)
+}
+
+// applyCurrency adds the currency to the request values unless it is the account default
+func applyCurrency(values url.Values, currency Currency) {
+	if len(currency) != 0 {
+		values.Set("CURRENCY", string(currency))
+	}
+}
@@ -0,0 +1,99 @@
+package payflow_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/japhy-team/paypal/payflow"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCurrencyValidateAmount(t *testing.T) {
+	assert.NoError(t, payflow.CurrencyUSD.ValidateAmount("3.50"))
+	assert.NoError(t, payflow.CurrencyGBP.ValidateAmount("3"))
+	assert.NoError(t, payflow.CurrencyJPY.ValidateAmount("1200"))
+	assert.NoError(t, payflow.Currency("").ValidateAmount("9999999.99"))
+
+	assert.Error(t, payflow.CurrencyCAD.ValidateAmount("3.505"))
+	assert.Error(t, payflow.CurrencyJPY.ValidateAmount("1200.50"))
+	assert.Error(t, payflow.CurrencyUSD.ValidateAmount("-3.50"))
+	assert.Error(t, payflow.CurrencyUSD.ValidateAmount("10000000.00"))
+	assert.Error(t, payflow.CurrencyUSD.ValidateAmount(""))
+	assert.Equal(t, 0, payflow.CurrencyHUF.Decimals())
+	assert.Equal(t, 2, payflow.CurrencyEUR.Decimals())
+}
+
+func TestDoSaleInCurrency(t *testing.T) {
+	var request url.Values
+	gateway := newTestGateway(t, func(r url.Values) url.Values {
+		request = r
+		return url.Values{"RESULT": {"0"}, "PNREF": {"A10A0B4E8D93"}}
+	})
+	gateway.SupportedCurrencies = []payflow.Currency{payflow.CurrencyUSD, payflow.CurrencyCAD, payflow.CurrencyGBP}
+
+	_, err := gateway.DoSale(payflow.PayPalCreditCard{PAN: Visa1, Amount: "12.99", Currency: payflow.CurrencyCAD, ExpDate: "1230"})
+	assert.NoError(t, err)
+	assert.Equal(t, "CAD", request.Get("CURRENCY"))
+	assert.Equal(t, "12.99", request.Get("AMT"))
+
+	_, err = gateway.DoCredit(payflow.PayPalCredit{PNREF: "A10A0B4E8D93", Amount: "2.99", Currency: payflow.CurrencyCAD})
+	assert.NoError(t, err)
+	assert.Equal(t, "CAD", request.Get("CURRENCY"))
+
+	_, err = gateway.DoSale(payflow.PayPalCreditCard{PAN: Visa1, Amount: "12.99", ExpDate: "1230"})
+	assert.NoError(t, err)
+	assert.Empty(t, request.Get("CURRENCY"))
+}
+
+func TestDoSaleInUnsupportedCurrency(t *testing.T) {
+	requests := 0
+	gateway := newTestGateway(t, func(r url.Values) url.Values {
+		requests++
+		return url.Values{"RESULT": {"0"}}
+	})
+	gateway.SupportedCurrencies = []payflow.Currency{payflow.CurrencyUSD, payflow.CurrencyCAD}
+
+	_, err := gateway.DoSale(payflow.PayPalCreditCard{PAN: Visa1, Amount: "12.99", Currency: payflow.CurrencyGBP, ExpDate: "1230"})
+	assert.EqualError(t, err, "payflow: the merchant account does not accept GBP, only USD, CAD")
+	assert.IsType(t, &payflow.UnsupportedCurrencyError{}, err)
+
+	_, err = gateway.DoCapture(payflow.PayPalCapture{PNREF: "A10A0B4E8D93", Currency: "cad"})
+	assert.IsType(t, &payflow.UnsupportedCurrencyError{}, err)
+
+	_, err = gateway.DoSale(payflow.PayPalCreditCard{PAN: Visa1, Amount: "12.999", Currency: payflow.CurrencyCAD, ExpDate: "1230"})
+	assert.Error(t, err)
+	assert.Equal(t, 0, requests)
+}
+
+func TestZeroDecimalCurrencies(t *testing.T) {
+	var request url.Values
+	gateway := newTestGateway(t, func(r url.Values) url.Values {
+		request = r
+		response := url.Values{"RESULT": {"0"}, "PNREF": {"A10A0B4E8D94"}}
+		if r.Get("AMT") == "5000" {
+			response.Set("AMT", "3000")
+			response.Set("ORIGAMT", "5000")
+		}
+		return response
+	})
+
+	_, err := gateway.VerifyCard(payflow.PayPalCreditCard{PAN: Visa1, Currency: payflow.CurrencyJPY, ExpDate: "1230"})
+	assert.NoError(t, err)
+	assert.Equal(t, "0", request.Get("AMT"))
+
+	result, err := gateway.DoPartialAuth(payflow.PayPalCreditCard{PAN: Visa1, Amount: "5000", Currency: payflow.CurrencyJPY, ExpDate: "1230"})
+	assert.NoError(t, err)
+	assert.True(t, result.IsPartial())
+	assert.Equal(t, "3000", result.ApprovedAmount)
+	assert.Equal(t, "2000", result.RemainingAmount)
+}
+
+func TestCurrencyOverXMLPay(t *testing.T) {
+	var documents []string
+	gateway := newTestXMLPayGateway(t, xmlPayApproved, &documents)
+
+	_, err := gateway.DoSale(payflow.PayPalCreditCard{PAN: Visa1, Amount: "12.99", Currency: payflow.CurrencyGBP, ExpDate: "1230"})
+	assert.NoError(t, err)
+	assert.Contains(t, documents[0], `<TotalAmt Currency="GBP">12.99</TotalAmt>`)
+}
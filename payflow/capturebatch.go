@@ -0,0 +1,321 @@
+package payflow
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// transientResults are the RESULT codes of captures that failed for reasons expected to go away on their own, such as
+// timeouts between Payflow, the processor and the issuer. The capture may have gone through anyway
+var transientResults = map[int]bool{
+	11:  true, // Client time-out waiting for response
+	104: true, // Timeout waiting for processor response
+	106: true, // Host not available
+	109: true, // Time-out waiting for host response
+	150: true, // Issuing bank timed out
+	151: true, // Issuing bank unavailable
+}
+
+// CaptureOutcome is how a capture of a batch ended
+type CaptureOutcome int
+
+// These constants are the outcomes of the captures of a batch
+const (
+	CaptureCaptured CaptureOutcome = iota // The authorization was captured
+	CaptureDeclined                       // Payflow or the processor declined the capture
+	CaptureExpired                        // The authorization expired before it could be captured
+	CaptureFailed                         // The capture could not be sent, or still failed after every retry
+	CaptureUnknown                        // The capture may have gone through but no inquiry could tell, it has to be reconciled
+)
+
+// notFoundResult is the RESULT of an inquiry that found no transaction sent with the customer reference
+const notFoundResult = 19
+
+func (o CaptureOutcome) String() string {
+	switch o {
+	case CaptureCaptured:
+		return "captured"
+	case CaptureDeclined:
+		return "declined"
+	case CaptureExpired:
+		return "expired"
+	case CaptureUnknown:
+		return "unknown"
+	default:
+		return "failed"
+	}
+}
+
+// CaptureBatchOptions tune how a batch of captures is run.
+// Concurrency is the number of captures in flight at once and Interval the least time between two requests to
+// Payflow, retries and inquiries included. MaxAttempts is how many times a capture is sent before it is reported as
+// failed. Each failed attempt is followed by an inquiry after RetryDelay, then twice as long after each attempt.
+// Zero values fall back to the defaults of DefaultCaptureBatchOptions, except Interval where zero means no rate limit.
+type CaptureBatchOptions struct {
+	Concurrency int
+	Interval    time.Duration
+	MaxAttempts int
+	RetryDelay  time.Duration
+}
+
+// DefaultCaptureBatchOptions are the options used for the fields left to zero
+var DefaultCaptureBatchOptions = CaptureBatchOptions{
+	Concurrency: 4,
+	MaxAttempts: 3,
+	RetryDelay:  2 * time.Second,
+}
+
+// CaptureResult is the outcome of one capture of a batch. Index is the position of the capture in the batch.
+// Values are the response of the last attempt, or of the inquiry that found the capture went through.
+// Err is the error of the last attempt, or of the inquiry that failed for a CaptureUnknown.
+type CaptureResult struct {
+	Index    int
+	Capture  PayPalCapture
+	Outcome  CaptureOutcome
+	Values   *PayPalValues
+	Err      error
+	Attempts int
+}
+
+// CaptureSummary reports on a batch once every capture ended. Each result is listed under its outcome, and the
+// captured amounts are totalled by currency
+type CaptureSummary struct {
+	Total           int
+	Captured        []CaptureResult
+	Declined        []CaptureResult
+	Expired         []CaptureResult
+	Failed          []CaptureResult
+	Unknown         []CaptureResult
+	CapturedAmounts map[Currency]string
+	Started         time.Time
+	Finished        time.Time
+}
+
+// CaptureBatch is a batch of captures being run. Its results can be read from Results as the captures end
+type CaptureBatch struct {
+	results chan CaptureResult
+	done    chan struct{}
+	summary *CaptureSummary
+}
+
+// Results streams the result of every capture as it ends and is closed once all of them did.
+// It holds every result of the batch, so it does not have to be read for the batch to complete
+func (b *CaptureBatch) Results() <-chan CaptureResult {
+	return b.results
+}
+
+// Wait blocks until every capture ended and returns the summary of the batch
+func (b *CaptureBatch) Wait() *CaptureSummary {
+	<-b.done
+	return b.summary
+}
+
+// StartCaptureBatch captures the authorizations (delayed capture) concurrently and returns right away.
+// Captures failing with a transient error are retried, but only after an inquiry by customer reference (CUSTREF)
+// found that the previous attempt did not go through after all, so that no authorization is captured twice.
+// When that inquiry fails too, the capture is not sent again and ends as CaptureUnknown, to be reconciled later.
+// Captures without a CustomerReference are given a random one for that purpose; one that is set has to be unique to
+// the capture.
+func (pClient *PayPalClient) StartCaptureBatch(captures []PayPalCapture, options CaptureBatchOptions) *CaptureBatch {
+	if options.Concurrency <= 0 {
+		options.Concurrency = DefaultCaptureBatchOptions.Concurrency
+	}
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = DefaultCaptureBatchOptions.MaxAttempts
+	}
+	if options.RetryDelay <= 0 {
+		options.RetryDelay = DefaultCaptureBatchOptions.RetryDelay
+	}
+
+	batch := &CaptureBatch{
+		results: make(chan CaptureResult, len(captures)),
+		done:    make(chan struct{}),
+		summary: &CaptureSummary{Total: len(captures), Started: time.Now()},
+	}
+
+	wait := func() {}
+	var ticker *time.Ticker
+	if options.Interval > 0 {
+		ticker = time.NewTicker(options.Interval)
+		wait = func() { <-ticker.C }
+	}
+
+	indexes := make(chan int)
+	results := make(chan CaptureResult)
+	var workers sync.WaitGroup
+	for i := 0; i < options.Concurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for index := range indexes {
+				result := pClient.captureWithRetries(captures[index], options, wait)
+				result.Index = index
+				results <- result
+			}
+		}()
+	}
+	go func() {
+		for index := range captures {
+			indexes <- index
+		}
+		close(indexes)
+		workers.Wait()
+		close(results)
+	}()
+
+	go func() {
+		totals := map[Currency]int64{}
+		for result := range results {
+			batch.summary.add(result, totals)
+			batch.results <- result
+		}
+		if ticker != nil {
+			ticker.Stop()
+		}
+		for currency, total := range totals {
+			if batch.summary.CapturedAmounts == nil {
+				batch.summary.CapturedAmounts = map[Currency]string{}
+			}
+			batch.summary.CapturedAmounts[currency] = formatAmount(total, currency)
+		}
+		batch.summary.Finished = time.Now()
+		close(batch.results)
+		close(batch.done)
+	}()
+	return batch
+}
+
+// add lists the result under its outcome and adds the amount of a capture to the totals
+func (s *CaptureSummary) add(result CaptureResult, totals map[Currency]int64) {
+	switch result.Outcome {
+	case CaptureCaptured:
+		s.Captured = append(s.Captured, result)
+		amount := result.Capture.Amount
+		if result.Values != nil && len(result.Values.Amount) != 0 {
+			amount = result.Values.Amount
+		}
+		if units, err := parseAmount(amount, result.Capture.Currency); err == nil {
+			totals[result.Capture.Currency] += units
+		}
+	case CaptureDeclined:
+		s.Declined = append(s.Declined, result)
+	case CaptureExpired:
+		s.Expired = append(s.Expired, result)
+	case CaptureUnknown:
+		s.Unknown = append(s.Unknown, result)
+	default:
+		s.Failed = append(s.Failed, result)
+	}
+}
+
+// captureWithRetries captures one authorization, retrying transient failures that an inquiry shows did not go through.
+// Every transient failure is followed by an inquiry, the last one included, so that a capture that went through is
+// never reported as failed
+func (pClient *PayPalClient) captureWithRetries(c PayPalCapture, options CaptureBatchOptions, wait func()) CaptureResult {
+	result := CaptureResult{Capture: c}
+	if len(c.Metadata.CustomerReference) == 0 {
+		reference, err := newCustomerReference()
+		if err != nil {
+			result.Outcome, result.Err = CaptureFailed, err
+			return result
+		}
+		c.Metadata.CustomerReference = reference
+		result.Capture = c
+	}
+	if err := pClient.validateCapture(c); err != nil {
+		result.Outcome, result.Err = CaptureFailed, err
+		return result
+	}
+	delay := options.RetryDelay
+
+	for {
+		result.Attempts++
+		wait()
+		res, err := pClient.DoCapture(c)
+		result.Values, result.Err = res, err
+		var transient bool
+		result.Outcome, transient = captureOutcome(res)
+		if !transient {
+			return result
+		}
+
+		time.Sleep(delay)
+		delay *= 2
+		// The capture may have gone through even though its response did not make it back
+		wait()
+		inquiry, err := pClient.DoInquiryByCustomerReference(c.Metadata.CustomerReference)
+		switch {
+		case err == nil && len(inquiry.OriginalPNREF) != 0 && inquiry.OriginalResult == 0:
+			result.Outcome, result.Values, result.Err = CaptureCaptured, inquiry, nil
+			return result
+		case !notCaptured(inquiry, err):
+			// Sending the capture again could capture the authorization twice
+			result.Outcome = CaptureUnknown
+			if err != nil {
+				result.Err = err
+			}
+			return result
+		}
+		if result.Attempts >= options.MaxAttempts {
+			result.Outcome = CaptureFailed
+			return result
+		}
+	}
+}
+
+// notCaptured reports whether an inquiry by customer reference positively shows that no capture went through: either
+// no transaction was sent with the customer reference, or the one found failed
+func notCaptured(inquiry *PayPalValues, err error) bool {
+	switch {
+	case inquiry == nil:
+		return false
+	case err != nil:
+		return inquiry.Result == notFoundResult
+	default:
+		return len(inquiry.OriginalPNREF) != 0 && inquiry.OriginalResult != 0
+	}
+}
+
+// captureOutcome classifies the response of a capture and tells whether it is worth retrying
+func captureOutcome(res *PayPalValues) (CaptureOutcome, bool) {
+	switch {
+	case res == nil:
+		// The capture was checked beforehand, so the request failed before Payflow answered
+		return CaptureFailed, true
+	case res.Result == 0:
+		return CaptureCaptured, false
+	case transientResults[res.Result]:
+		return CaptureFailed, true
+	case isExpiredAuthorization(res):
+		return CaptureExpired, false
+	default:
+		return CaptureDeclined, false
+	}
+}
+
+// expiredAuthorizationCode matches PayPal's error 10601, Authorization expired, as a code of its own rather than as part
+// of a longer number
+var expiredAuthorizationCode = regexp.MustCompile(`\b10601\b`)
+
+// isExpiredAuthorization reports whether a declined capture was declined because the authorization expired, which
+// PayPal reports with its error 10601 in the host code or the response messages. Free text such as "expired" is not
+// relied upon, as a declined card may be expired too
+func isExpiredAuthorization(res *PayPalValues) bool {
+	return res.HostCode == "10601" ||
+		expiredAuthorizationCode.MatchString(res.ResponseMessage) ||
+		expiredAuthorizationCode.MatchString(res.ExtraProcessorMessage)
+}
+
+// newCustomerReference returns a random customer reference as long as Payflow allows
+func newCustomerReference() (string, error) {
+	b := make([]byte, MaxCustomerReferenceLength/2)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("payflow: generating a customer reference: %w", err)
+	}
+	return strings.ToUpper(hex.EncodeToString(b)), nil
+}
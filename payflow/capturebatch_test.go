@@ -0,0 +1,155 @@
+package payflow_test
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/japhy-team/paypal/payflow"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartCaptureBatch(t *testing.T) {
+	var mutex sync.Mutex
+	captures := map[string]int{}
+	processed := map[string]string{} // CUSTREF of the captures that went through, with their PNREF
+	gateway := newTestGateway(t, func(r url.Values) url.Values {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if r.Get("TRXTYPE") == "I" {
+			if pnref, ok := processed[r.Get("CUSTREF")]; ok {
+				return url.Values{"RESULT": {"0"}, "PNREF": {"I" + pnref}, "ORIGPNREF": {pnref}, "ORIGRESULT": {"0"}, "AMT": {"20.00"}}
+			}
+			return url.Values{"RESULT": {"19"}, "RESPMSG": {"Original transaction ID not found"}}
+		}
+
+		origID := r.Get("ORIGID")
+		captures[origID]++
+		switch origID {
+		case "A2":
+			return url.Values{"RESULT": {"12"}, "RESPMSG": {"Declined"}}
+		case "A3":
+			return url.Values{"RESULT": {"12"}, "RESPMSG": {"Declined: 10601-Authorization has expired"}}
+		case "A4":
+			// The capture goes through, but the processor response does not make it back
+			processed[r.Get("CUSTREF")] = "D4"
+			return url.Values{"RESULT": {"104"}, "RESPMSG": {"Timeout waiting for processor response"}}
+		case "A5":
+			if captures[origID] == 1 {
+				panic(http.ErrAbortHandler)
+			}
+		case "A6":
+			return url.Values{"RESULT": {"106"}, "RESPMSG": {"Host not available"}}
+		case "A8":
+			return url.Values{"RESULT": {"12"}, "RESPMSG": {"Declined: card expired"}}
+		}
+		return url.Values{"RESULT": {"0"}, "PNREF": {"D" + origID[1:]}, "AMT": {r.Get("AMT")}}
+	})
+
+	batch := gateway.StartCaptureBatch([]payflow.PayPalCapture{
+		{PNREF: "A1", Amount: "10.00"},
+		{PNREF: "A2", Amount: "10.00"},
+		{PNREF: "A3", Amount: "10.00"},
+		{PNREF: "A4", Amount: "20.00"},
+		{PNREF: "A5", Amount: "5.25"},
+		{PNREF: "A6", Amount: "10.00"},
+		{PNREF: "A7", Amount: "10.001"},
+		{PNREF: "A8", Amount: "10.00"},
+	}, payflow.CaptureBatchOptions{Concurrency: 3, Interval: time.Millisecond, RetryDelay: time.Millisecond})
+
+	results := map[string]payflow.CaptureResult{}
+	for result := range batch.Results() {
+		results[result.Capture.PNREF] = result
+	}
+	summary := batch.Wait()
+
+	assert.Len(t, results, 8)
+	assert.Equal(t, 8, summary.Total)
+	assert.Len(t, summary.Captured, 3)
+	assert.Len(t, summary.Declined, 2)
+	assert.Len(t, summary.Expired, 1)
+	assert.Len(t, summary.Failed, 2)
+	assert.Equal(t, map[payflow.Currency]string{"": "35.25"}, summary.CapturedAmounts)
+
+	assert.Equal(t, payflow.CaptureDeclined, results["A2"].Outcome)
+	assert.Equal(t, payflow.CaptureExpired, results["A3"].Outcome)
+	assert.Equal(t, "A3", summary.Expired[0].Capture.PNREF)
+	// An expired card is declined, only PayPal's error 10601 tells that the authorization expired
+	assert.Equal(t, payflow.CaptureDeclined, results["A8"].Outcome)
+
+	// The inquiry found the first capture went through, it is not sent again
+	assert.Equal(t, payflow.CaptureCaptured, results["A4"].Outcome)
+	assert.Equal(t, "D4", results["A4"].Values.OriginalPNREF)
+	assert.Equal(t, 1, captures["A4"])
+	assert.Len(t, results["A4"].Capture.Metadata.CustomerReference, payflow.MaxCustomerReferenceLength)
+
+	assert.Equal(t, payflow.CaptureCaptured, results["A5"].Outcome)
+	assert.Equal(t, 2, results["A5"].Attempts)
+
+	assert.Equal(t, payflow.CaptureFailed, results["A6"].Outcome)
+	assert.Equal(t, 3, captures["A6"])
+	assert.Error(t, results["A6"].Err)
+
+	assert.Equal(t, payflow.CaptureFailed, results["A7"].Outcome)
+	assert.Equal(t, 0, captures["A7"])
+}
+
+func TestStartCaptureBatchDoesNotRetryWhenTheInquiryFails(t *testing.T) {
+	var mutex sync.Mutex
+	captures := 0
+	gateway := newTestGateway(t, func(r url.Values) url.Values {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if r.Get("TRXTYPE") == "I" {
+			panic(http.ErrAbortHandler)
+		}
+		captures++
+		return url.Values{"RESULT": {"104"}, "RESPMSG": {"Timeout waiting for processor response"}}
+	})
+
+	batch := gateway.StartCaptureBatch([]payflow.PayPalCapture{{PNREF: "A1", Amount: "10.00"}},
+		payflow.CaptureBatchOptions{RetryDelay: time.Millisecond})
+	summary := batch.Wait()
+
+	// Nobody knows whether the capture went through, sending it again could capture the authorization twice
+	assert.Equal(t, 1, captures)
+	if assert.Len(t, summary.Unknown, 1) {
+		assert.Equal(t, payflow.CaptureUnknown, summary.Unknown[0].Outcome)
+		assert.Equal(t, 1, summary.Unknown[0].Attempts)
+		assert.Error(t, summary.Unknown[0].Err)
+	}
+	assert.Empty(t, summary.Failed)
+}
+
+func TestStartCaptureBatchInquiresBeforeGivingUp(t *testing.T) {
+	var mutex sync.Mutex
+	captures := 0
+	gateway := newTestGateway(t, func(r url.Values) url.Values {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if r.Get("TRXTYPE") == "I" {
+			if captures < 2 {
+				return url.Values{"RESULT": {"19"}, "RESPMSG": {"Original transaction ID not found"}}
+			}
+			return url.Values{"RESULT": {"0"}, "PNREF": {"I1"}, "ORIGPNREF": {"D1"}, "ORIGRESULT": {"0"}, "AMT": {"10.00"}}
+		}
+		captures++
+		return url.Values{"RESULT": {"104"}, "RESPMSG": {"Timeout waiting for processor response"}}
+	})
+
+	batch := gateway.StartCaptureBatch([]payflow.PayPalCapture{{PNREF: "A1", Amount: "10.00"}},
+		payflow.CaptureBatchOptions{MaxAttempts: 2, RetryDelay: time.Millisecond})
+	summary := batch.Wait()
+
+	// The last attempt went through, which only the inquiry that follows it can tell
+	assert.Equal(t, 2, captures)
+	if assert.Len(t, summary.Captured, 1) {
+		assert.Equal(t, "D1", summary.Captured[0].Values.OriginalPNREF)
+	}
+}
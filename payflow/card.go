@@ -0,0 +1,61 @@
+package payflow
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/japhy-team/paypal/internal/mask"
+)
+
+// sensitiveParameters are the request parameters that carry card data. They are removed
+// from the request values as soon as the request has been sent.
+var sensitiveParameters = []string{"ACCT", "EXPDATE", "CVV2", "SWIPE"}
+
+// maskedCreditCard mirrors PayPalCreditCard without any of its methods so the fmt and json
+// packages fall back to their default behaviour when printing an already masked copy.
+type maskedCreditCard PayPalCreditCard
+
+// MaskPAN returns the card number with everything but the first 6 and the last 4 digits replaced by '*'.
+// Card numbers too short to keep both ends visible are masked entirely.
+func MaskPAN(pan string) string {
+	return mask.PAN(pan)
+}
+
+// masked returns a copy of the card that is safe to print or serialize. Track data is masked entirely
+func (c PayPalCreditCard) masked() maskedCreditCard {
+	m := maskedCreditCard(c)
+	m.PAN = mask.PAN(c.PAN)
+	m.CVV2 = mask.All(c.CVV2)
+	m.Swipe = mask.All(c.Swipe)
+	return m
+}
+
+// String returns the card with its PAN masked
+func (c PayPalCreditCard) String() string {
+	return mask.String(c.masked())
+}
+
+// GoString returns the Go syntax representation of the card with its PAN masked. It is used by the %#v verb
+func (c PayPalCreditCard) GoString() string {
+	return mask.GoString(c.masked(), "payflow.PayPalCreditCard")
+}
+
+// Format implements fmt.Formatter so that no verb can print the full card number
+func (c PayPalCreditCard) Format(f fmt.State, verb rune) {
+	mask.Format(f, verb, c.masked(), "payflow.PayPalCreditCard")
+}
+
+// MarshalJSON serializes the card with its PAN masked
+func (c PayPalCreditCard) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.masked())
+}
+
+// Wipe drops the card's references to its sensitive data once it is no longer needed, typically right after the
+// request was sent, so that the card can no longer leak it. It does not clear that data from memory: Go strings are
+// immutable and may be shared with the caller, so nothing can overwrite them.
+func (c *PayPalCreditCard) Wipe() {
+	c.PAN = ""
+	c.ExpDate = ""
+	c.CVV2 = ""
+	c.Swipe = ""
+}
@@ -0,0 +1,54 @@
+package payflow_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/japhy-team/paypal/payflow"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaskPAN(t *testing.T) {
+	assert.Equal(t, "411111******1111", payflow.MaskPAN(Visa1))
+	assert.Equal(t, "422222***2222", payflow.MaskPAN(Visa3))
+	assert.Equal(t, "**********", payflow.MaskPAN("1234567890"))
+	assert.Equal(t, "", payflow.MaskPAN(""))
+}
+
+func TestCreditCardNeverPrintsPAN(t *testing.T) {
+	card := payflow.PayPalCreditCard{
+		PAN:     Visa1,
+		Amount:  "3.50",
+		ExpDate: "1220",
+	}
+
+	for _, format := range []string{"%v", "%+v", "%#v", "%s", "%q", "%d", "%x"} {
+		printed := fmt.Sprintf(format, card)
+		assert.NotContains(t, printed, Visa1, format)
+		assert.Contains(t, printed, "411111******1111", format)
+		printed = fmt.Sprintf(format, &card)
+		assert.NotContains(t, printed, Visa1, format)
+	}
+	assert.True(t, strings.HasPrefix(card.GoString(), "payflow.PayPalCreditCard{"), card.GoString())
+
+	encoded, err := json.Marshal(card)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(encoded), Visa1)
+	assert.Contains(t, string(encoded), `"pan":"411111******1111"`)
+}
+
+func TestCreditCardWipe(t *testing.T) {
+	card := payflow.PayPalCreditCard{
+		PAN:     Visa1,
+		Amount:  "3.50",
+		ExpDate: "1220",
+	}
+
+	card.Wipe()
+	assert.Empty(t, card.PAN)
+	assert.Empty(t, card.ExpDate)
+	assert.Equal(t, "3.50", card.Amount)
+}
@@ -0,0 +1,230 @@
+package payflow
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// StoredCredential tells the card networks who initiated a transaction with a card on file and why (CARDONFILE)
+type StoredCredential string
+
+// These constants are the stored credential indicators.
+// Cardholder initiated transactions (CIT) happen while the cardholder takes part, merchant initiated transactions (MIT)
+// without them, under an agreement made during the initial transaction.
+const (
+	CardholderInitiatedInitial     StoredCredential = "CITI" // First transaction, when the card is stored
+	CardholderInitiatedUnscheduled StoredCredential = "CITU" // Cardholder pays with the card they stored
+	MerchantInitiatedRecurring     StoredCredential = "MITR" // Fixed amount charged at regular intervals
+	MerchantInitiatedInstallment   StoredCredential = "MITI" // Installment of a purchase
+	MerchantInitiatedUnscheduled   StoredCredential = "MITU" // Charge at a time or for an amount agreed on beforehand, such as a top up
+)
+
+// ErrStoredCardNotFound is returned by a TokenRepository that has no card stored under the requested ID
+var ErrStoredCardNotFound = errors.New("payflow: stored card not found")
+
+// StoredCard is a card saved for later transactions. The card data itself stays with Payflow: later transactions
+// reference the PNREF of the initial transaction (ORIGID) and the network transaction ID it returned (CCTRANSID).
+// Later transactions are conducted in the Currency of the initial transaction.
+type StoredCard struct {
+	ID                   string    `json:"id"`
+	PNREF                string    `json:"pnref"`
+	NetworkTransactionID string    `json:"networkTransactionId"`
+	MaskedPAN            string    `json:"maskedPan"`
+	Currency             Currency  `json:"currency,omitempty"`
+	LastPNREF            string    `json:"lastPnref,omitempty"`
+	CreatedAt            time.Time `json:"createdAt"`
+	LastUsedAt           time.Time `json:"lastUsedAt,omitempty"`
+}
+
+// TokenRepository persists stored cards
+type TokenRepository interface {
+	Save(card StoredCard) error
+	Find(id string) (StoredCard, error) // returns ErrStoredCardNotFound when there is no card stored under id
+	Delete(id string) error
+}
+
+// MemoryTokenRepository is a TokenRepository that keeps stored cards in memory. It is meant for tests and development
+type MemoryTokenRepository struct {
+	mutex sync.RWMutex
+	cards map[string]StoredCard
+}
+
+// NewMemoryTokenRepository returns an empty MemoryTokenRepository
+func NewMemoryTokenRepository() *MemoryTokenRepository {
+	return &MemoryTokenRepository{cards: map[string]StoredCard{}}
+}
+
+// Save stores the card, replacing any card stored under the same ID
+func (r *MemoryTokenRepository) Save(card StoredCard) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.cards[card.ID] = card
+	return nil
+}
+
+// Find returns the card stored under id
+func (r *MemoryTokenRepository) Find(id string) (StoredCard, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	card, ok := r.cards[id]
+	if !ok {
+		return StoredCard{}, ErrStoredCardNotFound
+	}
+	return card, nil
+}
+
+// Delete removes the card stored under id
+func (r *MemoryTokenRepository) Delete(id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.cards, id)
+	return nil
+}
+
+// CardOnFile stores cards after a successful cardholder initiated transaction and charges them later with the
+// stored credential indicators the card networks require.
+// It is safe for concurrent use: the operations on a card are serialized by id, so that a charge does not save back
+// a card that was replaced or removed meanwhile. Only one CardOnFile should use a repository, as it cannot serialize
+// the operations of another one
+type CardOnFile struct {
+	Client     *PayPalClient
+	Repository TokenRepository
+
+	mutex sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// NewCardOnFile returns a CardOnFile conducting transactions with client and storing cards in repository
+func NewCardOnFile(client *PayPalClient, repository TokenRepository) *CardOnFile {
+	return &CardOnFile{
+		Client:     client,
+		Repository: repository,
+	}
+}
+
+// Store conducts the initial cardholder initiated transaction (CARDONFILE=CITI) and, when it is approved, saves the
+// card under id. When card.Amount is empty the card is verified with a zero amount account verification and only
+// saved if it is Verified, otherwise card.Amount is authorized and the authorization can be captured or voided
+// like any other
+func (c *CardOnFile) Store(id string, card PayPalCreditCard) (*StoredCard, *PayPalValues, error) {
+	lock := c.lock(id)
+	lock.Lock()
+	defer lock.Unlock()
+
+	card.CardOnFile = CardholderInitiatedInitial
+	if len(card.Amount) == 0 {
+		verification, err := c.Client.VerifyCard(card)
+		if verification == nil {
+			return nil, nil, err
+		}
+		if err == nil && !verification.Verified {
+			err = errors.New("payflow: the card security code was rejected")
+		}
+		if err != nil {
+			return nil, verification.PayPalValues, err
+		}
+		return c.save(id, card, verification.PayPalValues)
+	}
+
+	res, err := c.Client.DoAuth(card, false)
+	if err != nil {
+		return nil, res, err
+	}
+	return c.save(id, card, res)
+}
+
+// save records the approved initial transaction of card under id
+func (c *CardOnFile) save(id string, card PayPalCreditCard, res *PayPalValues) (*StoredCard, *PayPalValues, error) {
+	stored := StoredCard{
+		ID:                   id,
+		PNREF:                res.PNREF,
+		NetworkTransactionID: res.CCTransID,
+		MaskedPAN:            MaskPAN(card.PAN),
+		Currency:             card.Currency,
+		CreatedAt:            time.Now().UTC(),
+	}
+	if err := c.Repository.Save(stored); err != nil {
+		return nil, res, err
+	}
+	return &stored, res, nil
+}
+
+// Charge conducts a sale for amount with the card stored under id (a reference transaction), recording the metadata
+// with it. An OrderID already used by another transaction fails with a DuplicateOrderError
+func (c *CardOnFile) Charge(id, amount string, credential StoredCredential, m TransactionMetadata) (*PayPalValues, error) {
+	return c.reference("S", id, amount, credential, m)
+}
+
+// Authorize conducts an authorization for amount with the card stored under id (a reference transaction), recording
+// the metadata with it. An OrderID already used by another transaction fails with a DuplicateOrderError
+func (c *CardOnFile) Authorize(id, amount string, credential StoredCredential, m TransactionMetadata) (*PayPalValues, error) {
+	return c.reference("A", id, amount, credential, m)
+}
+
+// Remove deletes the card stored under id
+func (c *CardOnFile) Remove(id string) error {
+	lock := c.lock(id)
+	lock.Lock()
+	defer lock.Unlock()
+	return c.Repository.Delete(id)
+}
+
+func (c *CardOnFile) reference(trxType, id, amount string, credential StoredCredential, m TransactionMetadata) (*PayPalValues, error) {
+	switch credential {
+	case CardholderInitiatedUnscheduled, MerchantInitiatedRecurring, MerchantInitiatedInstallment, MerchantInitiatedUnscheduled:
+	default:
+		return nil, fmt.Errorf("payflow: %q is not a stored credential indicator for subsequent transactions", credential)
+	}
+	lock := c.lock(id)
+	lock.Lock()
+	defer lock.Unlock()
+
+	stored, err := c.Repository.Find(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Client.validateAmount(amount, stored.Currency); err != nil {
+		return nil, err
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	values := url.Values{}
+	values.Set("TRXTYPE", trxType)
+	values.Set("TENDER", "C")
+	values.Set("ORIGID", stored.PNREF)
+	values.Set("AMT", amount)
+	applyCurrency(values, stored.Currency)
+	values.Set("CARDONFILE", string(credential))
+	if len(stored.NetworkTransactionID) != 0 {
+		values.Set("TXID", stored.NetworkTransactionID)
+	}
+	m.apply(values)
+
+	res, err := c.Client.transact(values)
+	if err != nil {
+		return res, err
+	}
+	stored.LastPNREF = res.PNREF
+	stored.LastUsedAt = time.Now().UTC()
+	return res, c.Repository.Save(stored)
+}
+
+// lock returns the lock serializing the operations on the card stored under id
+func (c *CardOnFile) lock(id string) *sync.Mutex {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.locks == nil {
+		c.locks = map[string]*sync.Mutex{}
+	}
+	lock, ok := c.locks[id]
+	if !ok {
+		lock = &sync.Mutex{}
+		c.locks[id] = lock
+	}
+	return lock
+}
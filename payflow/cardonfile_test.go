@@ -0,0 +1,106 @@
+package payflow_test
+
+import (
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/japhy-team/paypal/payflow"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCardOnFile(t *testing.T) {
+	var requests []url.Values
+	gateway := newTestGateway(t, func(r url.Values) url.Values {
+		requests = append(requests, r)
+		if len(r.Get("ORIGID")) == 0 {
+			return url.Values{"RESULT": {"0"}, "PNREF": {"B10P0C1D7F01"}, "CCTRANSID": {"MCC0000000001"}}
+		}
+		return url.Values{"RESULT": {"0"}, "PNREF": {"B10P0C1D7F02"}}
+	})
+	repository := payflow.NewMemoryTokenRepository()
+	cardOnFile := payflow.NewCardOnFile(gateway, repository)
+
+	stored, _, err := cardOnFile.Store("customer-42", payflow.PayPalCreditCard{PAN: MasterCard1, Amount: "1.00", ExpDate: "1230"})
+	assert.NoError(t, err)
+	assert.Equal(t, "B10P0C1D7F01", stored.PNREF)
+	assert.Equal(t, "MCC0000000001", stored.NetworkTransactionID)
+	assert.Equal(t, "555555******4444", stored.MaskedPAN)
+	assert.Equal(t, "CITI", requests[0].Get("CARDONFILE"))
+
+	response, err := cardOnFile.Charge("customer-42", "19.99", payflow.MerchantInitiatedRecurring, payflow.TransactionMetadata{
+		OrderID:           "ORDER-42-7",
+		CustomerReference: "customer-42",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "B10P0C1D7F02", response.PNREF)
+	assert.Equal(t, "S", requests[1].Get("TRXTYPE"))
+	assert.Equal(t, "B10P0C1D7F01", requests[1].Get("ORIGID"))
+	assert.Equal(t, "MITR", requests[1].Get("CARDONFILE"))
+	assert.Equal(t, "MCC0000000001", requests[1].Get("TXID"))
+	assert.Equal(t, "ORDER-42-7", requests[1].Get("ORDERID"))
+	assert.Equal(t, "customer-42", requests[1].Get("CUSTREF"))
+	assert.Empty(t, requests[1].Get("ACCT"))
+
+	saved, err := repository.Find("customer-42")
+	assert.NoError(t, err)
+	assert.Equal(t, "B10P0C1D7F02", saved.LastPNREF)
+
+	_, err = cardOnFile.Charge("customer-42", "19.99", payflow.CardholderInitiatedInitial, payflow.TransactionMetadata{})
+	assert.Error(t, err)
+	_, err = cardOnFile.Authorize("customer-7", "19.99", payflow.CardholderInitiatedUnscheduled, payflow.TransactionMetadata{})
+	assert.Equal(t, payflow.ErrStoredCardNotFound, err)
+	assert.Len(t, requests, 2)
+}
+
+func TestCardOnFileDoesNotStoreDeclinedCards(t *testing.T) {
+	gateway := newTestGateway(t, func(r url.Values) url.Values {
+		return url.Values{"RESULT": {"12"}, "RESPMSG": {"Declined"}}
+	})
+	repository := payflow.NewMemoryTokenRepository()
+
+	stored, response, err := payflow.NewCardOnFile(gateway, repository).Store("customer-42", payflow.PayPalCreditCard{PAN: Visa1, Amount: "1.00", ExpDate: "1230"})
+	assert.Error(t, err)
+	assert.Nil(t, stored)
+	assert.Equal(t, 12, response.Result)
+	_, err = repository.Find("customer-42")
+	assert.Equal(t, payflow.ErrStoredCardNotFound, err)
+}
+
+func TestCardOnFileRemoveWaitsForCharges(t *testing.T) {
+	charging, release := make(chan struct{}), make(chan struct{})
+	gateway := newTestGateway(t, func(r url.Values) url.Values {
+		if len(r.Get("ORIGID")) == 0 {
+			return url.Values{"RESULT": {"0"}, "PNREF": {"B10P0C1D7F01"}}
+		}
+		close(charging)
+		<-release
+		return url.Values{"RESULT": {"0"}, "PNREF": {"B10P0C1D7F02"}}
+	})
+	repository := payflow.NewMemoryTokenRepository()
+	cardOnFile := payflow.NewCardOnFile(gateway, repository)
+	_, _, err := cardOnFile.Store("customer-42", payflow.PayPalCreditCard{PAN: Visa1, Amount: "1.00", ExpDate: "1230"})
+	assert.NoError(t, err)
+
+	var done sync.WaitGroup
+	done.Add(2)
+	go func() {
+		defer done.Done()
+		_, err := cardOnFile.Charge("customer-42", "19.99", payflow.MerchantInitiatedUnscheduled, payflow.TransactionMetadata{})
+		assert.NoError(t, err)
+	}()
+	<-charging
+	go func() {
+		defer done.Done()
+		assert.NoError(t, cardOnFile.Remove("customer-42"))
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	done.Wait()
+
+	// The charge ended before the card was removed, it did not save it back
+	_, err = repository.Find("customer-42")
+	assert.Equal(t, payflow.ErrStoredCardNotFound, err)
+}
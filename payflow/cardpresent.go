@@ -0,0 +1,73 @@
+package payflow
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// EntryMode is how the card data of a card-present transaction was read at the point of sale (POSENTRYMODE).
+// The codes are the ISO 8583 POS entry modes the card networks use to tell apart fallback and contactless reads
+type EntryMode string
+
+// These constants are the entry modes of transactions sent with track data
+const (
+	EntryModeSwiped      EntryMode = "90" // The magnetic stripe was read in full, the default
+	EntryModeFallback    EntryMode = "80" // The chip could not be read and the magnetic stripe was read instead (EMV fallback)
+	EntryModeContactless EntryMode = "91" // Magnetic stripe data was read contactless
+)
+
+// validTrackData reports whether track looks like the track 1 or the track 2 data of a card, with or without
+// its start and end sentinels
+func validTrackData(track string) bool {
+	track = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(track, "%"), ";"), "?")
+	switch {
+	case strings.HasPrefix(track, "B"):
+		// Track 1: format code, PAN, name and expiration date separated by '^'
+		return strings.Count(track, "^") >= 2
+	case strings.Contains(track, "="):
+		// Track 2: PAN, then expiration date after the '=' separator
+		i := strings.IndexByte(track, '=')
+		return i > 0 && strings.Trim(track[:i], "0123456789") == "" && len(track)-i > 4
+	default:
+		return false
+	}
+}
+
+// validateCardPresent checks the track data of a card-present transaction, which replaces the keyed card data
+func (c PayPalCreditCard) validateCardPresent() error {
+	if len(c.Swipe) == 0 {
+		if len(c.EntryMode) != 0 {
+			return errors.New("payflow: an entry mode can only be sent with track data")
+		}
+		return nil
+	}
+	if len(c.PAN) != 0 || len(c.ExpDate) != 0 {
+		return errors.New("payflow: send either the track data or the card number and expiration date, not both")
+	}
+	if c.ThreeDSecure != nil {
+		return errors.New("payflow: 3-D Secure data cannot be sent with a card-present transaction")
+	}
+	if !validTrackData(c.Swipe) {
+		// The track data itself is left out of the error so it cannot end up in a log
+		return errors.New("payflow: the track data is neither track 1 nor track 2 data")
+	}
+	switch c.EntryMode {
+	case "", EntryModeSwiped, EntryModeFallback, EntryModeContactless:
+		return nil
+	default:
+		return errors.New("payflow: unknown entry mode " + string(c.EntryMode))
+	}
+}
+
+// applyCardPresent sends the track data in place of the card number and expiration date
+func (c PayPalCreditCard) applyCardPresent(values url.Values) {
+	values.Del("ACCT")
+	values.Del("EXPDATE")
+	values.Set("SWIPE", c.Swipe)
+	entryMode := c.EntryMode
+	if len(entryMode) == 0 {
+		entryMode = EntryModeSwiped
+	}
+	values.Set("POSENTRYMODE", string(entryMode))
+}
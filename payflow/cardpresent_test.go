@@ -0,0 +1,85 @@
+package payflow_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/japhy-team/paypal/payflow"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	sampleTrack1 = "%B4111111111111111^CARDHOLDER/TEST^3012101000000000000000000000000?"
+	sampleTrack2 = ";4111111111111111=30121010000000000000?"
+)
+
+func TestDoSaleCardPresent(t *testing.T) {
+	var request url.Values
+	gateway := newTestGateway(t, func(r url.Values) url.Values {
+		request = r
+		return url.Values{"RESULT": {"0"}, "PNREF": {"A10A0B4E8D95"}, "CCTRANS_POSDATA": {"4000000000010"}, "CARDTYPE": {"0"}}
+	})
+
+	response, err := gateway.DoSale(payflow.PayPalCreditCard{Swipe: sampleTrack2, Amount: "3.50"})
+	assert.NoError(t, err)
+	assert.Equal(t, sampleTrack2, request.Get("SWIPE"))
+	assert.Equal(t, "90", request.Get("POSENTRYMODE"))
+	assert.NotContains(t, request, "ACCT")
+	assert.NotContains(t, request, "EXPDATE")
+	assert.Equal(t, "4000000000010", response.CCTransPOSData)
+
+	_, err = gateway.DoAuth(payflow.PayPalCreditCard{Swipe: sampleTrack1, Amount: "3.50", EntryMode: payflow.EntryModeFallback}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "A", request.Get("TRXTYPE"))
+	assert.Equal(t, sampleTrack1, request.Get("SWIPE"))
+	assert.Equal(t, "80", request.Get("POSENTRYMODE"))
+}
+
+func TestDoSaleCardPresentRejectsInvalidData(t *testing.T) {
+	requests := 0
+	gateway := newTestGateway(t, func(r url.Values) url.Values {
+		requests++
+		return url.Values{"RESULT": {"0"}}
+	})
+
+	for _, card := range []payflow.PayPalCreditCard{
+		{Swipe: sampleTrack2, PAN: Visa1, Amount: "3.50"},
+		{Swipe: "not track data", Amount: "3.50"},
+		{Swipe: sampleTrack2, Amount: "3.50", EntryMode: "05"},
+		{Swipe: sampleTrack2, Amount: "3.50", ThreeDSecure: &payflow.ThreeDSecure{Status: payflow.AuthenticationSuccessful}},
+		{PAN: Visa1, ExpDate: "1230", Amount: "3.50", EntryMode: payflow.EntryModeSwiped},
+	} {
+		_, err := gateway.DoSale(card)
+		if assert.Error(t, err) {
+			assert.NotContains(t, err.Error(), "4111111111111111")
+		}
+	}
+	assert.Equal(t, 0, requests)
+}
+
+func TestCreditCardNeverPrintsTrackData(t *testing.T) {
+	card := payflow.PayPalCreditCard{Swipe: sampleTrack1, Amount: "3.50"}
+
+	for _, format := range []string{"%v", "%+v", "%#v", "%s", "%q"} {
+		assert.NotContains(t, fmt.Sprintf(format, card), "4111111111111111", format)
+	}
+	encoded, err := json.Marshal(card)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(encoded), "4111111111111111")
+
+	card.Wipe()
+	assert.Empty(t, card.Swipe)
+}
+
+func TestCardPresentOverXMLPay(t *testing.T) {
+	var documents []string
+	gateway := newTestXMLPayGateway(t, xmlPayApproved, &documents)
+
+	_, err := gateway.DoSale(payflow.PayPalCreditCard{Swipe: sampleTrack2, Amount: "3.50"})
+	assert.NoError(t, err)
+	assert.Contains(t, documents[0], "<Card><MagData>;4111111111111111=30121010000000000000?</MagData></Card>")
+	assert.Contains(t, documents[0], `<ExtData Name="POSENTRYMODE" Value="90"></ExtData>`)
+}
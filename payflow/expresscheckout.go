@@ -0,0 +1,177 @@
+package payflow
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// These constants specify the URL buyers are redirected to in order to approve an Express Checkout payment
+const (
+	PayPalCheckoutSandboxURL    = "https://www.sandbox.paypal.com/cgi-bin/webscr"
+	PayPalCheckoutProductionURL = "https://www.paypal.com/cgi-bin/webscr"
+)
+
+// ExpressCheckoutAction is the transaction type of an Express Checkout payment conducted through Payflow (TENDER=P).
+// The same action has to be used for every step of the checkout.
+type ExpressCheckoutAction string
+
+// These constants are the Express Checkout transaction types
+const (
+	ExpressCheckoutSale          ExpressCheckoutAction = "S"
+	ExpressCheckoutAuthorization ExpressCheckoutAction = "A"
+)
+
+// PayPalExpressCheckout is composed of the data required to run a PayPal wallet payment through the payflow API.
+// ReturnURL and CancelURL are where PayPal sends the buyer after they approved or cancelled the payment.
+// Currency is the currency of Amount, the default currency of the merchant account when empty.
+// Metadata is sent when the payment is completed, so that an OrderID is only checked for duplicates once
+type PayPalExpressCheckout struct {
+	Action    ExpressCheckoutAction `json:"action"`
+	Amount    string                `json:"amount"`
+	Currency  Currency              `json:"currency,omitempty"`
+	ReturnURL string                `json:"returnUrl"`
+	CancelURL string                `json:"cancelUrl"`
+	Metadata  TransactionMetadata   `json:"metadata"`
+}
+
+// ExpressCheckoutToken is returned when an Express Checkout is set up. The buyer has to be redirected to CheckoutURL
+type ExpressCheckoutToken struct {
+	*PayPalValues
+	Token       string `json:"TOKEN,omitempty"`
+	usedSandbox bool
+}
+
+// ExpressCheckoutDetails are the details of the buyer that approved an Express Checkout
+type ExpressCheckoutDetails struct {
+	*PayPalValues
+	Token         string `json:"TOKEN,omitempty"`
+	PayerID       string `json:"PAYERID,omitempty"`
+	PayerStatus   string `json:"PAYERSTATUS,omitempty"`
+	Email         string `json:"EMAIL,omitempty"`
+	FirstName     string `json:"FIRSTNAME,omitempty"`
+	LastName      string `json:"LASTNAME,omitempty"`
+	Phone         string `json:"PHONENUM,omitempty"`
+	CountryCode   string `json:"COUNTRYCODE,omitempty"`
+	ShipToName    string `json:"SHIPTONAME,omitempty"`
+	ShipToStreet  string `json:"SHIPTOSTREET,omitempty"`
+	ShipToCity    string `json:"SHIPTOCITY,omitempty"`
+	ShipToState   string `json:"SHIPTOSTATE,omitempty"`
+	ShipToZip     string `json:"SHIPTOZIP,omitempty"`
+	ShipToCountry string `json:"SHIPTOCOUNTRY,omitempty"`
+	AddressStatus string `json:"ADDRESSSTATUS,omitempty"`
+}
+
+// CheckoutURL is the PayPal URL the buyer has to be redirected to in order to approve the payment
+func (t *ExpressCheckoutToken) CheckoutURL() string {
+	query := url.Values{}
+	query.Set("cmd", "_express-checkout")
+	query.Set("token", t.Token)
+	checkoutURL := PayPalCheckoutProductionURL
+	if t.usedSandbox {
+		checkoutURL = PayPalCheckoutSandboxURL
+	}
+	return fmt.Sprintf("%s?%s", checkoutURL, query.Encode())
+}
+
+// expressCheckoutValues builds the request values shared by every step of an Express Checkout
+func expressCheckoutValues(action ExpressCheckoutAction, step string) url.Values {
+	values := url.Values{}
+	values.Set("TRXTYPE", string(action))
+	values.Set("TENDER", "P")
+	values.Set("ACTION", step)
+	return values
+}
+
+func (e PayPalExpressCheckout) validate() error {
+	switch e.Action {
+	case ExpressCheckoutSale, ExpressCheckoutAuthorization:
+		return e.Metadata.Validate()
+	default:
+		return errors.New("payflow: Express Checkout action must be ExpressCheckoutSale or ExpressCheckoutAuthorization")
+	}
+}
+
+// SetExpressCheckout starts an Express Checkout (ACTION=S) and returns the token identifying it.
+// The buyer then has to be redirected to the token's CheckoutURL to approve the payment
+func (pClient *PayPalClient) SetExpressCheckout(e PayPalExpressCheckout) (*ExpressCheckoutToken, error) {
+	if err := pClient.checkTender("P"); err != nil {
+		return nil, err
+	}
+	if err := e.validate(); err != nil {
+		return nil, err
+	}
+	if err := pClient.validateAmount(e.Amount, e.Currency); err != nil {
+		return nil, err
+	}
+	values := expressCheckoutValues(e.Action, "S")
+	values.Set("AMT", e.Amount)
+	applyCurrency(values, e.Currency)
+	values.Set("RETURNURL", e.ReturnURL)
+	values.Set("CANCELURL", e.CancelURL)
+
+	res, err := pClient.transact(values)
+	if res == nil {
+		return nil, err
+	}
+	return &ExpressCheckoutToken{
+		PayPalValues: res,
+		Token:        res.takeExtra("TOKEN"),
+		usedSandbox:  pClient.UsesSandbox,
+	}, err
+}
+
+// GetExpressCheckoutDetails returns the details of the buyer once they approved the payment (ACTION=G).
+// action must be the one the checkout was set up with
+func (pClient *PayPalClient) GetExpressCheckoutDetails(action ExpressCheckoutAction, token string) (*ExpressCheckoutDetails, error) {
+	if err := pClient.checkTender("P"); err != nil {
+		return nil, err
+	}
+	values := expressCheckoutValues(action, "G")
+	values.Set("TOKEN", token)
+
+	res, err := pClient.transact(values)
+	if res == nil {
+		return nil, err
+	}
+	return &ExpressCheckoutDetails{
+		PayPalValues:  res,
+		Token:         res.takeExtra("TOKEN"),
+		PayerID:       res.takeExtra("PAYERID"),
+		PayerStatus:   res.takeExtra("PAYERSTATUS"),
+		Email:         res.takeExtra("EMAIL"),
+		FirstName:     res.takeExtra("FIRSTNAME"),
+		LastName:      res.takeExtra("LASTNAME"),
+		Phone:         res.takeExtra("PHONENUM"),
+		CountryCode:   res.takeExtra("COUNTRYCODE"),
+		ShipToName:    res.takeExtra("SHIPTONAME"),
+		ShipToStreet:  res.takeExtra("SHIPTOSTREET"),
+		ShipToCity:    res.takeExtra("SHIPTOCITY"),
+		ShipToState:   res.takeExtra("SHIPTOSTATE"),
+		ShipToZip:     res.takeExtra("SHIPTOZIP"),
+		ShipToCountry: res.takeExtra("SHIPTOCOUNTRY"),
+		AddressStatus: res.takeExtra("ADDRESSSTATUS"),
+	}, err
+}
+
+// DoExpressCheckout completes the payment the buyer approved (ACTION=D).
+// The returned values carry the Payflow PNREF as well as the PayPal transaction ID in PPREF
+func (pClient *PayPalClient) DoExpressCheckout(e PayPalExpressCheckout, token, payerID string) (*PayPalValues, error) {
+	if err := pClient.checkTender("P"); err != nil {
+		return nil, err
+	}
+	if err := e.validate(); err != nil {
+		return nil, err
+	}
+	if err := pClient.validateAmount(e.Amount, e.Currency); err != nil {
+		return nil, err
+	}
+	values := expressCheckoutValues(e.Action, "D")
+	values.Set("TOKEN", token)
+	values.Set("PAYERID", payerID)
+	values.Set("AMT", e.Amount)
+	applyCurrency(values, e.Currency)
+	e.Metadata.apply(values)
+
+	return pClient.transact(values)
+}
@@ -0,0 +1,56 @@
+package payflow_test
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/japhy-team/paypal/payflow"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpressCheckout(t *testing.T) {
+	var requests []url.Values
+	gateway := newTestGateway(t, func(r url.Values) url.Values {
+		requests = append(requests, r)
+		switch r.Get("ACTION") {
+		case "S":
+			return url.Values{"RESULT": {"0"}, "TOKEN": {"EC-17C76533PL706494P"}}
+		case "G":
+			return url.Values{"RESULT": {"0"}, "TOKEN": {r.Get("TOKEN")}, "PAYERID": {"HN3RZ5X9BLGX6"}, "EMAIL": {"buyer@example.com"}}
+		default:
+			return url.Values{"RESULT": {"0"}, "PNREF": {"EFHP0D426838"}, "PPREF": {"8HU7A8XL2MKB5T4QE"}, "PENDINGREASON": {"none"}}
+		}
+	})
+
+	checkout := payflow.PayPalExpressCheckout{
+		Action:    payflow.ExpressCheckoutSale,
+		Amount:    "20.00",
+		ReturnURL: "http://localhost/return",
+		CancelURL: "http://localhost/cancel",
+	}
+	token, err := gateway.SetExpressCheckout(checkout)
+	assert.NoError(t, err)
+	assert.Equal(t, "EC-17C76533PL706494P", token.Token)
+	assert.True(t, strings.HasPrefix(token.CheckoutURL(), payflow.PayPalCheckoutSandboxURL+"?"))
+	assert.Contains(t, token.CheckoutURL(), "token=EC-17C76533PL706494P")
+
+	details, err := gateway.GetExpressCheckoutDetails(checkout.Action, token.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, "HN3RZ5X9BLGX6", details.PayerID)
+	assert.Equal(t, "buyer@example.com", details.Email)
+
+	payment, err := gateway.DoExpressCheckout(checkout, token.Token, details.PayerID)
+	assert.NoError(t, err)
+	assert.Equal(t, "8HU7A8XL2MKB5T4QE", payment.PPREF)
+	assert.Equal(t, "none", payment.PendingReason)
+
+	for i, action := range []string{"S", "G", "D"} {
+		assert.Equal(t, "P", requests[i].Get("TENDER"))
+		assert.Equal(t, "S", requests[i].Get("TRXTYPE"))
+		assert.Equal(t, action, requests[i].Get("ACTION"))
+	}
+	assert.Equal(t, "http://localhost/return", requests[0].Get("RETURNURL"))
+	assert.Equal(t, "HN3RZ5X9BLGX6", requests[2].Get("PAYERID"))
+}
@@ -0,0 +1,107 @@
+package payflow
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// These constants are the longest values Payflow accepts for the transaction metadata.
+// Some processors keep fewer characters of the invoice number and the comments
+const (
+	MaxInvoiceNumberLength     = 127
+	MaxCommentLength           = 128
+	MaxCustomerReferenceLength = 12
+	MaxOrderDescriptionLength  = 127
+	MaxOrderIDLength           = 64
+)
+
+// TransactionMetadata carries the references that tie a transaction to the order it was conducted for. They are
+// stored with the transaction and show up in the Payflow manager, in reports and in inquiries.
+// When OrderID is set Payflow checks it for duplicates: a transaction sent again with the same OrderID is not
+// conducted a second time, see DuplicateOrderError.
+type TransactionMetadata struct {
+	InvoiceNumber     string `json:"invoiceNumber,omitempty"`     // INVNUM
+	Comment1          string `json:"comment1,omitempty"`          // COMMENT1
+	Comment2          string `json:"comment2,omitempty"`          // COMMENT2
+	CustomerReference string `json:"customerReference,omitempty"` // CUSTREF, which inquiries can look transactions up by
+	OrderDescription  string `json:"orderDescription,omitempty"`  // ORDERDESC
+	OrderID           string `json:"orderId,omitempty"`           // ORDERID
+}
+
+// DuplicateOrderError is returned when a transaction was sent with an OrderID that Payflow already processed
+// (DUPLICATE=2). Nothing was conducted: the values returned along with it are those of the original transaction,
+// whose PNREF is PNREF. Whether that is a failure is up to the caller, a retried request usually treats it as success
+type DuplicateOrderError struct {
+	OrderID string
+	PNREF   string
+}
+
+func (e *DuplicateOrderError) Error() string {
+	return fmt.Sprintf("payflow: order %s was already processed as transaction %s", e.OrderID, e.PNREF)
+}
+
+// Validate checks the metadata against the field lengths Payflow accepts before it is sent
+func (m TransactionMetadata) Validate() error {
+	for _, field := range []struct {
+		name  string
+		value string
+		max   int
+	}{
+		{"INVNUM", m.InvoiceNumber, MaxInvoiceNumberLength},
+		{"COMMENT1", m.Comment1, MaxCommentLength},
+		{"COMMENT2", m.Comment2, MaxCommentLength},
+		{"CUSTREF", m.CustomerReference, MaxCustomerReferenceLength},
+		{"ORDERDESC", m.OrderDescription, MaxOrderDescriptionLength},
+		{"ORDERID", m.OrderID, MaxOrderIDLength},
+	} {
+		if err := checkLength(field.name, field.value, field.max); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply adds the metadata that is set to the request values
+func (m TransactionMetadata) apply(values url.Values) {
+	for _, field := range [][2]string{
+		{"INVNUM", m.InvoiceNumber},
+		{"COMMENT1", m.Comment1},
+		{"COMMENT2", m.Comment2},
+		{"CUSTREF", m.CustomerReference},
+		{"ORDERDESC", m.OrderDescription},
+		{"ORDERID", m.OrderID},
+	} {
+		if len(field[1]) != 0 {
+			values.Set(field[0], field[1])
+		}
+	}
+}
+
+// DoVoidWithMetadata voids an authorization or an unsettled sale like DoVoid, recording the metadata with the void
+func (pClient *PayPalClient) DoVoidWithMetadata(pnref string, m TransactionMetadata) (*PayPalValues, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	values := url.Values{}
+	values.Set("TRXTYPE", "V")
+	values.Set("TENDER", "C")
+	values.Set("ORIGID", pnref)
+	m.apply(values)
+
+	return pClient.transact(values)
+}
+
+// DoInquiryByCustomerReference returns the current state of the most recent transaction sent with the customer
+// reference (CUSTREF). It finds transactions whose PNREF never made it back, such as after a timeout
+func (pClient *PayPalClient) DoInquiryByCustomerReference(customerReference string) (*PayPalValues, error) {
+	if err := checkLength("CUSTREF", customerReference, MaxCustomerReferenceLength); err != nil {
+		return nil, err
+	}
+	values := url.Values{}
+	values.Set("TRXTYPE", "I")
+	values.Set("TENDER", "C")
+	values.Set("CUSTREF", customerReference)
+	values.Set("VERBOSITY", "HIGH")
+
+	return pClient.transact(values)
+}
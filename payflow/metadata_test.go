@@ -0,0 +1,99 @@
+package payflow_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/japhy-team/paypal/payflow"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var sampleMetadata = payflow.TransactionMetadata{
+	InvoiceNumber:     "INV-1001",
+	Comment1:          "web order",
+	Comment2:          "express shipping",
+	CustomerReference: "CUST-42",
+	OrderDescription:  "2 pairs of running shoes",
+	OrderID:           "ORDER-1001",
+}
+
+func TestDoSaleWithMetadata(t *testing.T) {
+	var request url.Values
+	gateway := newTestGateway(t, func(r url.Values) url.Values {
+		request = r
+		return url.Values{"RESULT": {"0"}, "PNREF": {"A10A0B4E8D90"}}
+	})
+
+	_, err := gateway.DoSale(payflow.PayPalCreditCard{PAN: Visa1, Amount: "3.50", ExpDate: "1230", Metadata: sampleMetadata})
+	assert.NoError(t, err)
+	assert.Equal(t, "INV-1001", request.Get("INVNUM"))
+	assert.Equal(t, "web order", request.Get("COMMENT1"))
+	assert.Equal(t, "express shipping", request.Get("COMMENT2"))
+	assert.Equal(t, "CUST-42", request.Get("CUSTREF"))
+	assert.Equal(t, "2 pairs of running shoes", request.Get("ORDERDESC"))
+	assert.Equal(t, "ORDER-1001", request.Get("ORDERID"))
+
+	_, err = gateway.DoVoidWithMetadata("A10A0B4E8D90", payflow.TransactionMetadata{Comment1: "customer cancelled"})
+	assert.NoError(t, err)
+	assert.Equal(t, "V", request.Get("TRXTYPE"))
+	assert.Equal(t, "customer cancelled", request.Get("COMMENT1"))
+	assert.Empty(t, request.Get("ORDERID"))
+
+	_, err = gateway.DoCredit(payflow.PayPalCredit{PNREF: "A10A0B4E8D90", Metadata: payflow.TransactionMetadata{InvoiceNumber: "CN-7"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "CN-7", request.Get("INVNUM"))
+}
+
+func TestDoSaleWithProcessedOrderID(t *testing.T) {
+	gateway := newTestGateway(t, func(r url.Values) url.Values {
+		return url.Values{"RESULT": {"0"}, "PNREF": {"A10A0B4E8D91"}, "DUPLICATE": {"2"}}
+	})
+
+	response, err := gateway.DoSale(payflow.PayPalCreditCard{PAN: Visa1, Amount: "3.50", ExpDate: "1230", Metadata: sampleMetadata})
+	if assert.IsType(t, &payflow.DuplicateOrderError{}, err) {
+		assert.Equal(t, &payflow.DuplicateOrderError{OrderID: "ORDER-1001", PNREF: "A10A0B4E8D91"}, err)
+	}
+	assert.Equal(t, "A10A0B4E8D91", response.PNREF)
+}
+
+func TestDoInquiryByCustomerReference(t *testing.T) {
+	var request url.Values
+	gateway := newTestGateway(t, func(r url.Values) url.Values {
+		request = r
+		return url.Values{
+			"RESULT": {"0"}, "PNREF": {"A10A0B4E8D92"}, "ORIGRESULT": {"0"}, "TRANSSTATE": {"6"},
+			"CUSTREF": {"CUST-42"}, "INVNUM": {"INV-1001"}, "COMMENT1": {"web order"}, "ORDERID": {"ORDER-1001"},
+		}
+	})
+
+	response, err := gateway.DoInquiryByCustomerReference("CUST-42")
+	assert.NoError(t, err)
+	assert.Equal(t, "I", request.Get("TRXTYPE"))
+	assert.Equal(t, "CUST-42", request.Get("CUSTREF"))
+	assert.Empty(t, request.Get("ORIGID"))
+	assert.Equal(t, "CUST-42", response.CustomerReference)
+	assert.Equal(t, "INV-1001", response.InvoiceNumber)
+	assert.Equal(t, "web order", response.Comment1)
+	assert.Equal(t, "ORDER-1001", response.OrderID)
+	assert.Equal(t, 6, response.TransactionState)
+}
+
+func TestTransactionMetadataValidate(t *testing.T) {
+	assert.NoError(t, sampleMetadata.Validate())
+	assert.Error(t, payflow.TransactionMetadata{CustomerReference: "CUSTOMER-0042"}.Validate())
+
+	_, err := newTestGateway(t, nil).DoCapture(payflow.PayPalCapture{PNREF: "A10A0B4E8D90", Metadata: payflow.TransactionMetadata{CustomerReference: "CUSTOMER-0042"}})
+	assert.Error(t, err)
+}
+
+func TestMetadataOverXMLPay(t *testing.T) {
+	var documents []string
+	gateway := newTestXMLPayGateway(t, xmlPayApproved, &documents)
+
+	_, err := gateway.DoSale(payflow.PayPalCreditCard{PAN: Visa1, Amount: "3.50", ExpDate: "1230", Metadata: sampleMetadata})
+	assert.NoError(t, err)
+	assert.Contains(t, documents[0], "<Invoice><InvNum>INV-1001</InvNum><Description>2 pairs of running shoes</Description>"+
+		"<TotalAmt>3.50</TotalAmt><Comment>web order</Comment><CustRef>CUST-42</CustRef></Invoice>")
+	assert.Contains(t, documents[0], `<ExtData Name="COMMENT2" Value="express shipping"></ExtData><ExtData Name="ORDERID" Value="ORDER-1001"></ExtData>`)
+}
@@ -0,0 +1,207 @@
+package payflow
+
+import (
+	"errors"
+	"fmt"
+)
+
+// PartialAuthResult is the outcome of an authorization that the issuer was allowed to approve for less than requested.
+// RequestedAmount is what was asked for, ApprovedAmount what the issuer approved, and RemainingAmount what is still
+// owed and has to be collected from another form of payment. Balance is the balance left on the card (BALAMT),
+// only set when the issuer reports it. All amounts are in Currency.
+type PartialAuthResult struct {
+	Values          *PayPalValues
+	Currency        Currency
+	RequestedAmount string
+	ApprovedAmount  string
+	RemainingAmount string
+	Balance         string
+	voided          bool
+}
+
+// IncompleteAuthorizationError is returned by AuthorizeAcrossCards when the cards could not cover the order total.
+// Every authorization collected along the way has been voided, except the ones listed in VoidFailures by PNREF.
+type IncompleteAuthorizationError struct {
+	Total        string
+	Authorized   string
+	VoidFailures map[string]error
+}
+
+func (e *IncompleteAuthorizationError) Error() string {
+	message := "Payflow authorizations of " + e.Authorized + " do not cover the total of " + e.Total
+	if len(e.VoidFailures) != 0 {
+		message += fmt.Sprintf(", %d authorization(s) could not be voided", len(e.VoidFailures))
+	}
+	return message
+}
+
+// AbortedAuthorizationError is returned by AuthorizeAcrossCards when a request failed and some of the authorizations
+// collected so far could not be voided. Err is the error of the failed request, VoidFailures the errors of the voids
+// that failed by PNREF: those authorizations still hold funds on the cards.
+type AbortedAuthorizationError struct {
+	Err          error
+	VoidFailures map[string]error
+}
+
+func (e *AbortedAuthorizationError) Error() string {
+	return fmt.Sprintf("%v, %d authorization(s) could not be voided", e.Err, len(e.VoidFailures))
+}
+
+// Unwrap returns the error of the failed request
+func (e *AbortedAuthorizationError) Unwrap() error {
+	return e.Err
+}
+
+// DoPartialAuth conducts an authorization for c.Amount that may be approved for less than the requested amount.
+// A declined authorization returns both the result and the PayPalError. A failed request returns no result.
+func (pClient *PayPalClient) DoPartialAuth(c PayPalCreditCard) (*PartialAuthResult, error) {
+	values := cardValues("A", c)
+	values.Set("PARTIALAUTH", "Y")
+	values.Set("VERBOSITY", "HIGH")
+
+	res, err := pClient.cardTransact(values, c)
+	if res == nil {
+		return nil, err
+	}
+	result, convErr := newPartialAuthResult(c.Amount, c.Currency, res)
+	if err != nil {
+		return result, err
+	}
+	return result, convErr
+}
+
+func newPartialAuthResult(requested string, currency Currency, v *PayPalValues) (*PartialAuthResult, error) {
+	result := &PartialAuthResult{
+		Values:          v,
+		Currency:        currency,
+		RequestedAmount: requested,
+		ApprovedAmount:  formatAmount(0, currency),
+		RemainingAmount: requested,
+		Balance:         v.BalanceAmount,
+	}
+	if v.Result != 0 {
+		return result, nil
+	}
+	if len(v.OriginalAmount) != 0 {
+		result.RequestedAmount = v.OriginalAmount
+	}
+
+	requestedCents, err := parseAmount(result.RequestedAmount, currency)
+	if err != nil {
+		return result, err
+	}
+	approvedCents := requestedCents
+	if len(v.Amount) != 0 {
+		if approvedCents, err = parseAmount(v.Amount, currency); err != nil {
+			return result, err
+		}
+	}
+	result.ApprovedAmount = formatAmount(approvedCents, currency)
+	result.RemainingAmount = formatAmount(requestedCents-approvedCents, currency)
+	return result, nil
+}
+
+// IsPartial reports whether the issuer approved less than the requested amount
+func (r *PartialAuthResult) IsPartial() bool {
+	return r.Values.Result == 0 && r.RemainingAmount != formatAmount(0, r.Currency)
+}
+
+// Accept keeps the authorization so it can later be captured, and returns its PNREF.
+// The remaining amount has to be collected from another form of payment.
+func (r *PartialAuthResult) Accept() (string, error) {
+	if r.voided {
+		return "", errors.New("payflow: cannot accept authorization " + r.Values.PNREF + ", it has been voided")
+	}
+	if r.Values.Result != 0 {
+		return "", errors.New("payflow: cannot accept authorization " + r.Values.PNREF + ", it was not approved")
+	}
+	return r.Values.PNREF, nil
+}
+
+// Void rejects the authorization and sends the issuer an authorization reversal for the approved amount
+func (r *PartialAuthResult) Void(pClient *PayPalClient) (*PayPalValues, error) {
+	res, err := pClient.DoVoid(r.Values.PNREF)
+	if err == nil {
+		r.voided = true
+	}
+	return res, err
+}
+
+// AuthorizeAcrossCards authorizes total across the given cards, in order, until it is covered.
+// Each card is asked for whatever is still owed and may be partially approved; declined cards are skipped.
+// The Amount of the cards is ignored, total is in the Currency of the cards, which all have to share it.
+// When the cards run out before the total is covered, or a request fails, every authorization collected so far is voided
+// and the authorizations are returned along with the error. When some of these voids fail, the error of a failed request
+// is wrapped in an AbortedAuthorizationError listing them.
+func (pClient *PayPalClient) AuthorizeAcrossCards(total string, cards []PayPalCreditCard) ([]*PartialAuthResult, error) {
+	var currency Currency
+	for i, card := range cards {
+		if i == 0 {
+			currency = card.Currency
+		} else if card.Currency != currency {
+			return nil, fmt.Errorf("payflow: cannot authorize %s and %s across cards", currency, card.Currency)
+		}
+	}
+	if err := pClient.checkCurrency(currency); err != nil {
+		return nil, err
+	}
+	remaining, err := parseAmount(total, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	var authorizations []*PartialAuthResult
+	for _, card := range cards {
+		if remaining <= 0 {
+			break
+		}
+		card.Amount = formatAmount(remaining, currency)
+
+		result, err := pClient.DoPartialAuth(card)
+		if result != nil && result.Values.Result == 0 && err != nil {
+			// Approved, but the approved amount could not be read. It is released with the others
+			authorizations = append(authorizations, result)
+		}
+		if result == nil || result.Values.Result == 0 && err != nil {
+			// Whatever was authorized so far is released before giving up
+			if failures := pClient.voidAll(authorizations); len(failures) != 0 {
+				return authorizations, &AbortedAuthorizationError{Err: err, VoidFailures: failures}
+			}
+			return authorizations, err
+		}
+		if result.Values.Result != 0 {
+			continue
+		}
+
+		approved, _ := parseAmount(result.ApprovedAmount, currency)
+		if approved <= 0 {
+			continue
+		}
+		authorizations = append(authorizations, result)
+		remaining -= approved
+	}
+
+	if remaining > 0 {
+		totalCents, _ := parseAmount(total, currency)
+		return authorizations, &IncompleteAuthorizationError{
+			Total:        formatAmount(totalCents, currency),
+			Authorized:   formatAmount(totalCents-remaining, currency),
+			VoidFailures: pClient.voidAll(authorizations),
+		}
+	}
+	return authorizations, nil
+}
+
+// voidAll voids every authorization and returns the errors of the voids that failed by PNREF
+func (pClient *PayPalClient) voidAll(authorizations []*PartialAuthResult) map[string]error {
+	var failures map[string]error
+	for _, authorization := range authorizations {
+		if _, err := authorization.Void(pClient); err != nil {
+			if failures == nil {
+				failures = map[string]error{}
+			}
+			failures[authorization.Values.PNREF] = err
+		}
+	}
+	return failures
+}
@@ -0,0 +1,113 @@
+package payflow_test
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/japhy-team/paypal/payflow"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// prepaidGateway approves authorizations up to the balance of each card and records the voids it receives
+func prepaidGateway(t *testing.T, balances map[string]string, voided *[]string) *payflow.PayPalClient {
+	return newTestGateway(t, func(request url.Values) url.Values {
+		response := url.Values{}
+		switch request.Get("TRXTYPE") {
+		case "V":
+			*voided = append(*voided, request.Get("ORIGID"))
+			response.Set("RESULT", "0")
+			response.Set("PNREF", "V"+request.Get("ORIGID"))
+		case "A":
+			balance, ok := balances[request.Get("ACCT")]
+			if !ok {
+				response.Set("RESULT", "12")
+				response.Set("RESPMSG", "Declined")
+				return response
+			}
+			response.Set("RESULT", "0")
+			response.Set("PNREF", "A"+request.Get("ACCT")[12:])
+			response.Set("AMT", request.Get("AMT"))
+			available, _ := strconv.ParseFloat(balance, 64)
+			requested, _ := strconv.ParseFloat(request.Get("AMT"), 64)
+			if available < requested {
+				response.Set("AMT", balance)
+				response.Set("ORIGAMT", request.Get("AMT"))
+				response.Set("BALAMT", "0.00")
+			}
+		}
+		return response
+	})
+}
+
+func TestDoPartialAuth(t *testing.T) {
+	var voided []string
+	gateway := prepaidGateway(t, map[string]string{Visa1: "80.00"}, &voided)
+
+	result, err := gateway.DoPartialAuth(payflow.PayPalCreditCard{PAN: Visa1, Amount: "100.00", ExpDate: "1230"})
+	assert.NoError(t, err)
+	assert.True(t, result.IsPartial())
+	assert.Equal(t, "100.00", result.RequestedAmount)
+	assert.Equal(t, "80.00", result.ApprovedAmount)
+	assert.Equal(t, "20.00", result.RemainingAmount)
+	assert.Equal(t, "0.00", result.Balance)
+
+	_, err = result.Void(gateway)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A1111"}, voided)
+	_, err = result.Accept()
+	assert.Error(t, err)
+}
+
+func TestAuthorizeAcrossCards(t *testing.T) {
+	var voided []string
+	gateway := prepaidGateway(t, map[string]string{Visa1: "60.00", Visa2: "99.00"}, &voided)
+
+	authorizations, err := gateway.AuthorizeAcrossCards("100.00", []payflow.PayPalCreditCard{
+		{PAN: Visa1, ExpDate: "1230"},
+		{PAN: MasterCard1, ExpDate: "1230"},
+		{PAN: Visa2, ExpDate: "1230"},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, authorizations, 2)
+	assert.Equal(t, "60.00", authorizations[0].ApprovedAmount)
+	assert.Equal(t, "40.00", authorizations[1].ApprovedAmount)
+	assert.False(t, authorizations[1].IsPartial())
+	assert.Empty(t, voided)
+}
+
+func TestAuthorizeAcrossCardsVoidsWhenTotalIsNotCovered(t *testing.T) {
+	var voided []string
+	gateway := prepaidGateway(t, map[string]string{Visa1: "60.00", Visa2: "10.00"}, &voided)
+
+	authorizations, err := gateway.AuthorizeAcrossCards("100.00", []payflow.PayPalCreditCard{
+		{PAN: Visa1, ExpDate: "1230"},
+		{PAN: Visa2, ExpDate: "1230"},
+	})
+	assert.IsType(t, &payflow.IncompleteAuthorizationError{}, err)
+	assert.Equal(t, "70.00", err.(*payflow.IncompleteAuthorizationError).Authorized)
+	assert.Len(t, authorizations, 2)
+	assert.Equal(t, []string{"A1111", "A1881"}, voided)
+}
+
+func TestAuthorizeAcrossCardsReportsVoidFailuresWhenARequestFails(t *testing.T) {
+	gateway := newTestGateway(t, func(request url.Values) url.Values {
+		if request.Get("TRXTYPE") == "V" {
+			return url.Values{"RESULT": {"12"}, "RESPMSG": {"Declined"}}
+		}
+		return url.Values{"RESULT": {"0"}, "PNREF": {"A1111"}, "AMT": {"60.00"}, "ORIGAMT": {"100.00"}}
+	})
+
+	authorizations, err := gateway.AuthorizeAcrossCards("100.00", []payflow.PayPalCreditCard{
+		{PAN: Visa1, ExpDate: "1230"},
+		{PAN: Visa2, ExpDate: "1230", Metadata: payflow.TransactionMetadata{OrderID: strings.Repeat("9", 200)}},
+	})
+	assert.Len(t, authorizations, 1)
+	if assert.IsType(t, &payflow.AbortedAuthorizationError{}, err) {
+		aborted := err.(*payflow.AbortedAuthorizationError)
+		assert.Error(t, aborted.Err)
+		assert.Contains(t, aborted.VoidFailures, "A1111")
+	}
+}
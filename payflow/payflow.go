@@ -1,10 +1,13 @@
 package payflow
 
 import (
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // These constants specify the URL that the library hits
@@ -13,23 +16,68 @@ const (
 	PayflowProductionURL = "https://payflowpro.paypal.com"
 )
 
+// Protocol is the format requests are sent to Payflow in
+type Protocol int
+
+// These constants are the protocols Payflow accepts. Card transactions work the same over both, but XMLPay only
+// carries card tenders: ACH (TENDER=A) and Express Checkout (TENDER=P) transactions fail with an
+// UnsupportedProtocolError before anything is sent when the client uses ProtocolXMLPay
+const (
+	ProtocolNVP    Protocol = iota // Name-value pairs, the default
+	ProtocolXMLPay                 // XMLPay 2.0 documents, for card transactions only
+)
+
 // PayPalClient is the type you should use for your Payflow API Requests
+// SupportedCurrencies are the currencies the merchant account accepts. Transactions in any other currency fail with an
+// UnsupportedCurrencyError before anything is sent. When it is empty every currency is sent and left to Payflow to check
 type PayPalClient struct {
-	Username    string
-	Password    string
-	Vendor      string
-	Partner     string
-	Endpoint    string
-	UsesSandbox bool
-	Client      *http.Client
+	Username            string
+	Password            string
+	Vendor              string
+	Partner             string
+	Endpoint            string
+	UsesSandbox         bool
+	Client              *http.Client
+	Protocol            Protocol
+	SupportedCurrencies []Currency
 }
 
 // PayPalCreditCard is composed of the data required to conduct a transaction against the payflow API with a credit card.
 // ExpirationDate is of the format MMYY
+// Currency is the currency of Amount, the default currency of the merchant account when empty
+// CVV2 and the billing street and zip are optional and are checked by the issuer when sent
+// ThreeDSecure is optional and carries the result of a 3-D Secure authentication of the cardholder
+// Purchase is optional and carries the Level 2 / Level 3 data of commercial cards
+// CardOnFile is the stored credential indicator, required when the card is being stored for later transactions
+// Metadata is optional and ties the transaction to the order it was conducted for
+// Swipe is the track 1 or track 2 data of a card-present transaction, read from the magnetic stripe. It replaces the
+// PAN and the expiration date, which are then left empty. EntryMode tells how it was read.
+// The card data is held in strings, which this package cannot clear from memory: Wipe only drops the card's
+// references to it, and the data remains in memory until the garbage collector reuses it.
 type PayPalCreditCard struct {
-	PAN     string `json:"pan"`
-	Amount  string `json:"amount"`
-	ExpDate string `json:"expirationDate"`
+	PAN          string              `json:"pan"`
+	Amount       string              `json:"amount"`
+	Currency     Currency            `json:"currency,omitempty"`
+	ExpDate      string              `json:"expirationDate"`
+	CVV2         string              `json:"cvv2,omitempty"`
+	BillToStreet string              `json:"billToStreet,omitempty"`
+	BillToZip    string              `json:"billToZip,omitempty"`
+	ThreeDSecure *ThreeDSecure       `json:"threeDSecure,omitempty"`
+	Purchase     *PurchaseCardData   `json:"purchase,omitempty"`
+	CardOnFile   StoredCredential    `json:"cardOnFile,omitempty"`
+	Metadata     TransactionMetadata `json:"metadata"`
+	Swipe        string              `json:"swipe,omitempty"`
+	EntryMode    EntryMode           `json:"entryMode,omitempty"`
+}
+
+// PayPalCredit is composed of the data required to refund a transaction.
+// Amount may be left empty to refund the full amount of the original transaction. Currency has to be the one of the
+// original transaction.
+type PayPalCredit struct {
+	PNREF    string              `json:"pnref"`
+	Amount   string              `json:"amount,omitempty"`
+	Currency Currency            `json:"currency,omitempty"`
+	Metadata TransactionMetadata `json:"metadata"`
 }
 
 // PayPalResponse encompases a generic response from PayFlow
@@ -47,23 +95,35 @@ type PayPalValues struct {
 	Amount                string `json:"AMT,omitempty"`
 	AmexID                string `json:"AMEXID,omitempty"`    // VERBOSITY=HIGH
 	AmexPOSID             string `json:"AMEXPOSID,omitempty"` //VERBOSITY=HIGH
+	AuthenticationStatus  string `json:"AUTHENTICATION_STATUS,omitempty"`
 	AuthCode              string `json:"AUTHCODE,omitempty"`
 	AVSAddress            string `json:"AVSADDR,omitempty"`
 	AVSZipcode            string `json:"AVSZIP,omitempty"`
 	AVSInternational      string `json:"IAVS,omitempty"`
+	BalanceAmount         string `json:"BALAMT,omitempty"`   // Balance left on a prepaid card, returned with VERBOSITY=HIGH when the issuer reports it
 	CardType              string `json:"CARDTYPE,omitempty"` //VERBOSITY=HIGH
 	CorrelationID         string `json:"CORRELATIONID,omitempty"`
 	CCTransID             string `json:"CCTRANSID,omitempty"`
 	CCTransPOSData        string `json:"CCTRANS_POSDATA,omitempty"`
+	Comment1              string `json:"COMMENT1,omitempty"`
+	Comment2              string `json:"COMMENT2,omitempty"`
+	CustomerReference     string `json:"CUSTREF,omitempty"`
 	CVV2Match             rune   `json:"CVV2MATCH,omitempty"`
 	DateToSettle          string `json:"DATE_TO_SETTLE,omitempty"` //This parameter is returned in the response for inquiry transactions only (TRXTYPE=I)
 	Duplicate             string `json:"DUPLICATE,omitempty"`      // - DUPLICATE=2 — ORDERID has already been submitted in a previous request with the same ORDERID.  - DUPLICATE=1 — The request ID has already been submitted for a previous request.  - DUPLICATE=-1 — The Gateway database is not available. PayPal cannot determine whether this is a duplicate order or request.
+	ECI                   string `json:"ECI,omitempty"`
 	EmailMatch            rune   `json:"EMAILMATCH,omitempty"`
 	ExtraProcessorMessage string `json:"EXTRAPMSG,omitempty"`
 	HostCode              string `json:"HOSTCODE,omitempty"` //VERBOSITY=HIGH
+	InvoiceNumber         string `json:"INVNUM,omitempty"`
+	OrderDescription      string `json:"ORDERDESC,omitempty"`
+	OrderID               string `json:"ORDERID,omitempty"`
 	OriginalAmount        string `json:"ORIGAMT,omitempty"`
+	OriginalPNREF         string `json:"ORIGPNREF,omitempty"`         // Inquiries only, the PNREF of the transaction found
+	OriginalResult        int    `json:"ORIGRESULT,omitempty"`        // Inquiries only, the RESULT of the transaction found
 	PaymentAdviceCode     string `json:"PAYMENTADVICECODE,omitempty"` // A value of 03 or 21 indicates it is the merchant's responsibility to stop this recurring transaction. These two codes indicate that either the account was closed, fraud was involved, or the cardholder has asked the bank to stop this payment for another reason. Even if a re-attempted transaction is successful, it will likely result in a chargeback.
 	PaymentType           string `json:"PAYMENTTYPE,omitempty"`
+	PendingReason         string `json:"PENDINGREASON,omitempty"` // Express Checkout payments (TENDER=P) only
 	PhoneMatch            rune   `json:"PHONEMATCH,omitempty"`
 	PNREF                 string `json:"PNREF,omitempty"`
 	PPREF                 string `json:"PPREF,omitempty"`
@@ -75,6 +135,11 @@ type PayPalValues struct {
 	ResponseText          string `json:"RESPTEXT,omitempty"` //VERBOSITY=HIGH
 	TimeOfTransaction     string `json:"TRANSTIME,omitempty"`
 	TransactionState      int    `json:"TRANSSTATE,omitempty"` // State of the transaction sent in an Inquiry response or with errors associated with Fraud Protection Service (FPS) transactions
+
+	TransactionTime time.Time           `json:"-"`                      // TRANSTIME parsed with TimeLayout
+	SettlementTime  time.Time           `json:"-"`                      // DATE_TO_SETTLE parsed with TimeLayout
+	ThreeDSecure    *ThreeDSecureResult `json:"threeDSecure,omitempty"` // Liability shift outcome of card transactions that carried 3-D Secure data
+	Extra           map[string]string   `json:"extra,omitempty"`        // Response values this package does not know, by name
 }
 
 // PayPalError is used when RESP is anything but 0.
@@ -113,83 +178,206 @@ func (pClient *PayPalClient) performRequest(values url.Values) (*PayPalResponse,
 	values.Add("PARTNER", pClient.Partner)
 	values.Add("VENDOR", pClient.Vendor)
 
-	formResponse, err := pClient.Client.PostForm(pClient.Endpoint, values)
+	post := pClient.postNVP
+	if pClient.Protocol == ProtocolXMLPay {
+		post = pClient.postXMLPay
+	}
+	responseValues, err := post(values)
+	for _, key := range sensitiveParameters {
+		values.Del(key)
+	}
 	if err != nil {
 		return nil, err
 	}
-	defer formResponse.Body.Close()
 
+	response := &PayPalResponse{UsedSandbox: pClient.UsesSandbox}
+	response.Result = responseValues.Get("RESULT")
+	response.ResponseMessage = responseValues.Get("RESPMSG")
+	response.Values = responseValues
+
+	if response.Result != "0" {
+		pError := PayPalError{}
+		pError.ErrorCode = response.Result
+		pError.ErrorMessage = response.ResponseMessage
+
+		err = &pError
+	}
+
+	return response, err
+}
+
+// postNVP sends the request as name-value pairs and parses the name-value pairs of the response
+func (pClient *PayPalClient) postNVP(values url.Values) (url.Values, error) {
+	formResponse, err := pClient.Client.PostForm(pClient.Endpoint, values)
 	if err != nil {
 		return nil, err
 	}
+	defer formResponse.Body.Close()
+
 	body, err := ioutil.ReadAll(formResponse.Body)
 	if err != nil {
 		return nil, err
 	}
+	return url.ParseQuery(string(body))
+}
 
-	responseValues, err := url.ParseQuery(string(body))
-	response := &PayPalResponse{UsedSandbox: pClient.UsesSandbox}
-	if err == nil {
-		response.Result = responseValues.Get("RESULT")
-		response.ResponseMessage = responseValues.Get("RESPMSG")
-		response.Values = responseValues
+// ResponseParseError is returned when the gateway approved a transaction but some of the response values could not be
+// parsed. The values that could be parsed are returned along with it, the others are left to their zero value.
+// A declined transaction is reported with a PayPalError instead
+type ResponseParseError struct {
+	Errors []error
+}
+
+func (e *ResponseParseError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return "payflow: invalid response: " + strings.Join(messages, "; ")
+}
+
+// TimeLayout is the layout of the times Payflow returns, such as TRANSTIME and DATE_TO_SETTLE
+const TimeLayout = "2006-01-02 15:04:05"
+
+// responseParser reads the response values one key at a time, collecting the keys it read and the values it
+// could not parse
+type responseParser struct {
+	values url.Values
+	read   map[string]bool
+	errors []error
+}
+
+func (p *responseParser) string(key string) string {
+	p.read[key] = true
+	return parseString(p.values[key])
+}
+
+func (p *responseParser) rune(key string) rune {
+	return parseRune(p.string(key))
+}
 
-		if response.Result != "0" {
-			pError := PayPalError{}
-			pError.ErrorCode = response.Result
-			pError.ErrorMessage = response.ResponseMessage
+func (p *responseParser) int(key string) int {
+	value := p.string(key)
+	if len(value) == 0 {
+		return 0
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		p.errors = append(p.errors, fmt.Errorf("%s is not an integer: %q", key, value))
+	}
+	return i
+}
+
+// amount returns the amount as sent, once it is known to be one
+func (p *responseParser) amount(key string) string {
+	value := p.string(key)
+	if len(value) == 0 {
+		return ""
+	}
+	if _, err := parseAmount(value, ""); err != nil {
+		p.errors = append(p.errors, fmt.Errorf("%s is not an amount: %q", key, value))
+		return ""
+	}
+	return value
+}
+
+func (p *responseParser) time(key string) time.Time {
+	value := p.string(key)
+	if len(value) == 0 {
+		return time.Time{}
+	}
+	t, err := time.Parse(TimeLayout, value)
+	if err != nil {
+		p.errors = append(p.errors, fmt.Errorf("%s is not a time: %q", key, value))
+	}
+	return t
+}
 
-			err = &pError
+// extra returns the values of every key that was not read
+func (p *responseParser) extra() map[string]string {
+	var extra map[string]string
+	for key := range p.values {
+		if p.read[key] {
+			continue
+		}
+		if extra == nil {
+			extra = map[string]string{}
 		}
+		extra[key] = parseString(p.values[key])
 	}
+	return extra
+}
 
-	return response, err
+// parseResponse converts the response into PayPalValues. Every value is parsed even when some cannot be, the
+// returned error is a *ResponseParseError listing those that could not
+func parseResponse(paypalResponse *PayPalResponse) (*PayPalValues, error) {
+	p := &responseParser{values: paypalResponse.Values, read: map[string]bool{}}
+	v := &PayPalValues{
+		AdditionalMessages:    p.string("ADDLMSGS"),
+		Amount:                p.amount("AMT"),
+		AmexID:                p.string("AMEXID"),
+		AmexPOSID:             p.string("AMEXPOSID"),
+		AuthenticationStatus:  p.string("AUTHENTICATION_STATUS"),
+		AuthCode:              p.string("AUTHCODE"),
+		AVSAddress:            p.string("AVSADDR"),
+		AVSZipcode:            p.string("AVSZIP"),
+		AVSInternational:      p.string("IAVS"),
+		BalanceAmount:         p.amount("BALAMT"),
+		CardType:              p.string("CARDTYPE"),
+		CorrelationID:         p.string("CORRELATIONID"),
+		CCTransID:             p.string("CCTRANSID"),
+		CCTransPOSData:        p.string("CCTRANS_POSDATA"),
+		Comment1:              p.string("COMMENT1"),
+		Comment2:              p.string("COMMENT2"),
+		CustomerReference:     p.string("CUSTREF"),
+		CVV2Match:             p.rune("CVV2MATCH"),
+		DateToSettle:          p.string("DATE_TO_SETTLE"),
+		Duplicate:             p.string("DUPLICATE"),
+		ECI:                   p.string("ECI"),
+		EmailMatch:            p.rune("EMAILMATCH"),
+		ExtraProcessorMessage: p.string("EXTRAPMSG"),
+		HostCode:              p.string("HOSTCODE"),
+		InvoiceNumber:         p.string("INVNUM"),
+		OrderDescription:      p.string("ORDERDESC"),
+		OrderID:               p.string("ORDERID"),
+		OriginalAmount:        p.amount("ORIGAMT"),
+		OriginalPNREF:         p.string("ORIGPNREF"),
+		OriginalResult:        p.int("ORIGRESULT"),
+		PaymentAdviceCode:     p.string("PAYMENTADVICECODE"),
+		PaymentType:           p.string("PAYMENTTYPE"),
+		PendingReason:         p.string("PENDINGREASON"),
+		PhoneMatch:            p.rune("PHONEMATCH"),
+		PNREF:                 p.string("PNREF"),
+		PPREF:                 p.string("PPREF"),
+		ProCardSecure:         p.rune("PROCCARDSECURE"),
+		ProcessorAVS:          p.rune("PROCAVS"),
+		ProcessorCVV2:         p.rune("PROCCVV2"),
+		Result:                p.int("RESULT"),
+		ResponseMessage:       p.string("RESPMSG"),
+		ResponseText:          p.string("RESPTEXT"),
+		TimeOfTransaction:     p.string("TRANSTIME"),
+		TransactionState:      p.int("TRANSSTATE"),
+
+		TransactionTime: p.time("TRANSTIME"),
+		SettlementTime:  p.time("DATE_TO_SETTLE"),
+	}
+	v.Extra = p.extra()
+
+	if len(p.errors) != 0 {
+		return v, &ResponseParseError{Errors: p.errors}
+	}
+	return v, nil
 }
 
-//parseString(paypalResponse.Values["AMT"])
-func convertResponse(paypalResponse *PayPalResponse) *PayPalValues {
-	result, _ := strconv.Atoi(parseString(paypalResponse.Values["RESULT"]))
-	transactionState, _ := strconv.Atoi(parseString(paypalResponse.Values["TRANSSATE"]))
-	cvv2Match := parseRune(parseString(paypalResponse.Values["CVV2MATCH"]))
-	emailMatch := parseRune(parseString(paypalResponse.Values["EMAILMATCH"]))
-	phoneMatch := parseRune(parseString(paypalResponse.Values["PHONEMATCH"]))
-	proCardSecure := parseRune(parseString(paypalResponse.Values["PROCCARDSECURE"]))
-	processorAVS := parseRune(parseString(paypalResponse.Values["PROCAVS"]))
-	processorCVV2 := parseRune(parseString(paypalResponse.Values["PROCCVV2"]))
-	return &PayPalValues{
-		AdditionalMessages:    parseString(paypalResponse.Values["ADDLMSGS"]),
-		Amount:                parseString(paypalResponse.Values["AMT"]),
-		AmexID:                parseString(paypalResponse.Values["AMEXID"]),
-		AmexPOSID:             parseString(paypalResponse.Values["AMEXPOSID"]),
-		AuthCode:              parseString(paypalResponse.Values["AUTHCODE"]),
-		AVSAddress:            parseString(paypalResponse.Values["AVSADDR"]),
-		AVSZipcode:            parseString(paypalResponse.Values["AVSZIP"]),
-		AVSInternational:      parseString(paypalResponse.Values["IAVS"]),
-		CardType:              parseString(paypalResponse.Values["CARDTYPE"]),
-		CorrelationID:         parseString(paypalResponse.Values["CORRELATIONID"]),
-		CCTransID:             parseString(paypalResponse.Values["CCTRANSID"]),
-		CCTransPOSData:        parseString(paypalResponse.Values["CCTRANS_POSDATA"]),
-		CVV2Match:             cvv2Match,
-		DateToSettle:          parseString(paypalResponse.Values["DATE_TO_SETTLE"]), //This parameter is returned in the response for inquiry transactions only (TRXTYPE=I)
-		Duplicate:             parseString(paypalResponse.Values["DUPLICATE"]),      // - DUPLICATE=2 — ORDERID has already been submitted in a previous request with the same ORDERID.  - DUPLICATE=1 — The request ID has already been submitted for a previous request.  - DUPLICATE=-1 — The Gateway database is not available. PayPal cannot determine whether this is a duplicate order or request.
-		EmailMatch:            emailMatch,
-		ExtraProcessorMessage: parseString(paypalResponse.Values["EXTRAPMSG"]),
-		HostCode:              parseString(paypalResponse.Values["HOSTCODE"]),
-		OriginalAmount:        parseString(paypalResponse.Values["ORIGAMT"]),
-		PaymentAdviceCode:     parseString(paypalResponse.Values["PAYMENTADVICECODE"]), // A value of 03 or 21 indicates it is the merchant's responsibility to stop this recurring transaction. These two codes indicate that either the account was closed, fraud was involved, or the cardholder has asked the bank to stop this payment for another reason. Even if a re-attempted transaction is successful, it will likely result in a chargeback.
-		PaymentType:           parseString(paypalResponse.Values["PAYMENTTYPE"]),
-		PhoneMatch:            phoneMatch,
-		PNREF:                 parseString(paypalResponse.Values["PNREF"]),
-		PPREF:                 parseString(paypalResponse.Values["PPREF"]),
-		ProCardSecure:         proCardSecure,
-		ProcessorAVS:          processorAVS,
-		ProcessorCVV2:         processorCVV2, //VERBOSITY=HIGH
-		Result:                result,
-		ResponseMessage:       parseString(paypalResponse.Values["RESPMSG"]),
-		ResponseText:          parseString(paypalResponse.Values["RESPTEXT"]),
-		TimeOfTransaction:     parseString(paypalResponse.Values["TRANSTIME"]),
-		TransactionState:      transactionState,
+// takeExtra returns the value of a key that is not part of PayPalValues and removes it from Extra. It is used by the
+// operations returning values of their own
+func (v *PayPalValues) takeExtra(key string) string {
+	value := v.Extra[key]
+	delete(v.Extra, key)
+	if len(v.Extra) == 0 {
+		v.Extra = nil
 	}
+	return value
 }
 
 // parseString is a helper function for convert response. this simple functionality
@@ -209,38 +397,164 @@ func parseRune(s string) rune {
 	return rune(0)
 }
 
-// DoSale conducts a sale operation against payflow
-// PayPalCreditCard have a Card Number (PAN), Amount specified, and an expiration data in the format of MMYY
-func (pClient *PayPalClient) DoSale(c PayPalCreditCard) (*PayPalValues, error) {
+// transact performs the request and parses the response.
+// When the request could not be sent or no response arrived the values are nil and the error is the transport error.
+// Otherwise the values are returned along with a *PayPalError when the transaction was declined, a
+// *DuplicateOrderError when its ORDERID was already processed, or a *ResponseParseError when it was approved but
+// part of the response could not be parsed
+func (pClient *PayPalClient) transact(values url.Values) (*PayPalValues, error) {
+	orderID := values.Get("ORDERID")
+	res, err := pClient.performRequest(values)
+	if res == nil {
+		return nil, err
+	}
+	v, parseErr := parseResponse(res)
+	switch {
+	case err != nil:
+	case v.Duplicate == "2":
+		err = &DuplicateOrderError{OrderID: orderID, PNREF: v.PNREF}
+	default:
+		err = parseErr
+	}
+	return v, err
+}
+
+// cardValues builds the request values shared by every transaction conducted with a credit card
+func cardValues(trxType string, c PayPalCreditCard) url.Values {
 	values := url.Values{}
-	values.Set("TRXTYPE", "S")
+	values.Set("TRXTYPE", trxType)
 	values.Set("TENDER", "C")
 	values.Set("ACCT", c.PAN)
 	values.Set("AMT", c.Amount)
+	applyCurrency(values, c.Currency)
 	values.Set("EXPDATE", c.ExpDate)
+	if len(c.CVV2) != 0 {
+		values.Set("CVV2", c.CVV2)
+	}
+	if len(c.BillToStreet) != 0 {
+		values.Set("BILLTOSTREET", c.BillToStreet)
+	}
+	if len(c.BillToZip) != 0 {
+		values.Set("BILLTOZIP", c.BillToZip)
+	}
+	if c.ThreeDSecure != nil {
+		c.ThreeDSecure.apply(values)
+	}
+	if c.Purchase != nil {
+		c.Purchase.apply(values)
+	}
+	if len(c.CardOnFile) != 0 {
+		values.Set("CARDONFILE", string(c.CardOnFile))
+	}
+	c.Metadata.apply(values)
+	if len(c.Swipe) != 0 {
+		c.applyCardPresent(values)
+	}
+	return values
+}
 
-	res, err := pClient.performRequest(values)
-	// log.Printf("%v", res.Values)
-	return convertResponse(res), err
+// cardTransact validates the card data that cannot be left to the gateway, performs the card transaction and adds
+// what only the card knows to the result
+func (pClient *PayPalClient) cardTransact(values url.Values, c PayPalCreditCard) (*PayPalValues, error) {
+	if err := pClient.checkCurrency(c.Currency); err != nil {
+		return nil, err
+	}
+	if len(c.Amount) != 0 {
+		if err := c.Currency.ValidateAmount(c.Amount); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.Metadata.Validate(); err != nil {
+		return nil, err
+	}
+	if err := c.validateCardPresent(); err != nil {
+		return nil, err
+	}
+	if c.ThreeDSecure != nil {
+		if err := c.ThreeDSecure.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if c.Purchase != nil {
+		if err := c.Purchase.validate(c.Currency); err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := pClient.transact(values)
+	if res != nil && c.ThreeDSecure != nil {
+		res.ThreeDSecure = c.ThreeDSecure.result(res)
+	}
+	return res, err
+}
+
+// DoSale conducts a sale operation against payflow
+// PayPalCreditCard have a Card Number (PAN), Amount specified, and an expiration data in the format of MMYY
+// Card-present sales send the track data read at the point of sale in Swipe instead
+func (pClient *PayPalClient) DoSale(c PayPalCreditCard) (*PayPalValues, error) {
+	values := cardValues("S", c)
+
+	return pClient.cardTransact(values, c)
 }
 
 // DoAuth conducts an authorization against payflow
 // PayPalCreditCard have a Card Number (PAN), Amount specified, and an expiration data in the format of MMYY
+// Card-present authorizations send the track data read at the point of sale in Swipe instead
 // isPartialAuthorization specifies if a partial authorization is acceptable. Read Below notes about authorizations for more information
 func (pClient *PayPalClient) DoAuth(c PayPalCreditCard, isPartialAuthorization bool) (*PayPalValues, error) {
-	values := url.Values{}
-	values.Set("TRXTYPE", "A")
-	values.Set("TENDER", "C")
-	values.Set("ACCT", c.PAN)
-	values.Set("AMT", c.Amount)
-	values.Set("EXPDATE", c.ExpDate)
+	values := cardValues("A", c)
 	if isPartialAuthorization {
 		values.Set("PARTIALAUTH", "Y")
 		values.Set("VERBOSITY", "HIGH")
 	}
 
-	res, err := pClient.performRequest(values)
-	return convertResponse(res), err
+	return pClient.cardTransact(values, c)
+}
+
+// DoVoid voids an authorization (or a sale that has not settled yet) identified by the PNREF the gateway returned for it.
+// Voiding an authorization releases the hold on the buyer's funds
+func (pClient *PayPalClient) DoVoid(pnref string) (*PayPalValues, error) {
+	values := url.Values{}
+	values.Set("TRXTYPE", "V")
+	values.Set("TENDER", "C")
+	values.Set("ORIGID", pnref)
+
+	return pClient.transact(values)
+}
+
+// DoCredit refunds a settled sale or capture identified by the PNREF the gateway returned for it (referenced credit).
+// Amount may be left empty to refund the full amount
+func (pClient *PayPalClient) DoCredit(c PayPalCredit) (*PayPalValues, error) {
+	if err := pClient.validateAmount(c.Amount, c.Currency); err != nil {
+		return nil, err
+	}
+	if err := c.Metadata.Validate(); err != nil {
+		return nil, err
+	}
+	values := url.Values{}
+	values.Set("TRXTYPE", "C")
+	values.Set("TENDER", "C")
+	values.Set("ORIGID", c.PNREF)
+	if len(c.Amount) != 0 {
+		values.Set("AMT", c.Amount)
+	}
+	applyCurrency(values, c.Currency)
+	c.Metadata.apply(values)
+
+	return pClient.transact(values)
+}
+
+// DoInquiry returns the current state of the transaction identified by the PNREF the gateway returned for it.
+// TransactionState and DateToSettle are only returned by inquiries.
+// See DoInquiryByCustomerReference to look a transaction up by the reference it was sent with
+func (pClient *PayPalClient) DoInquiry(pnref string) (*PayPalValues, error) {
+	values := url.Values{}
+	values.Set("TRXTYPE", "I")
+	values.Set("TENDER", "C")
+	values.Set("ORIGID", pnref)
+	values.Set("VERBOSITY", "HIGH")
+
+	return pClient.transact(values)
 }
 
 // Submitting Partial Authorizations
@@ -258,3 +572,5 @@ func (pClient *PayPalClient) DoAuth(c PayPalCreditCard, isPartialAuthorization b
 
 // Accept the $80.00 and ask the buyer to provide an alternate payment for the additional $20.00.
 // Reject the partial authorization and submit to the card issuer an authorization reversal (Void) for $80.00.
+
+// See DoPartialAuth and AuthorizeAcrossCards for helpers that take care of both actions.
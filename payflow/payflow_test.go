@@ -1,9 +1,14 @@
 package payflow_test
 
 import (
+	"io/ioutil"
 	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/japhy-team/paypal/payflow"
 
@@ -197,3 +202,74 @@ func fetchEnvVars() (username, password, partner, vendor string) {
 	}
 	return
 }
+
+// newTestGateway starts a stand-in Payflow gateway that answers every request with the values returned by respond
+// and returns a client pointed at it
+func newTestGateway(t *testing.T, respond func(request url.Values) url.Values) *payflow.PayPalClient {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request, err := url.ParseQuery(string(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(respond(request).Encode()))
+	}))
+	t.Cleanup(server.Close)
+
+	testClient := payflow.NewClient("user", "password", "partner", "vendor", true)
+	testClient.Endpoint = server.URL
+	return testClient
+}
+
+func TestDoInquiryParsesResponse(t *testing.T) {
+	gateway := newTestGateway(t, func(r url.Values) url.Values {
+		return url.Values{
+			"RESULT": {"0"}, "PNREF": {"A10A0B4E8D8A"}, "RESPMSG": {"Approved"}, "AMT": {"3.50"}, "TRANSSTATE": {"8"},
+			"TRANSTIME": {"2020-06-02 13:45:12"}, "DATE_TO_SETTLE": {"2020-06-02 17:00:00"}, "NEWFIELD": {"value"},
+		}
+	})
+
+	response, err := gateway.DoInquiry("A10A0B4E8D8A")
+	assert.NoError(t, err)
+	assert.Equal(t, 8, response.TransactionState)
+	assert.Equal(t, "3.50", response.Amount)
+	assert.Equal(t, time.Date(2020, 6, 2, 13, 45, 12, 0, time.UTC), response.TransactionTime)
+	assert.Equal(t, time.Date(2020, 6, 2, 17, 0, 0, 0, time.UTC), response.SettlementTime)
+	assert.Equal(t, map[string]string{"NEWFIELD": "value"}, response.Extra)
+}
+
+func TestDoSaleReportsParseErrors(t *testing.T) {
+	gateway := newTestGateway(t, func(r url.Values) url.Values {
+		return url.Values{"RESULT": {"0"}, "PNREF": {"A10A0B4E8D8B"}, "AMT": {"3.5O"}, "TRANSTIME": {"yesterday"}}
+	})
+
+	response, err := gateway.DoSale(payflow.PayPalCreditCard{PAN: Visa1, Amount: "3.50", ExpDate: "1230"})
+	if assert.IsType(t, &payflow.ResponseParseError{}, err) {
+		assert.Len(t, err.(*payflow.ResponseParseError).Errors, 2)
+	}
+	assert.Equal(t, "A10A0B4E8D8B", response.PNREF)
+	assert.Empty(t, response.Amount)
+}
+
+func TestDoSaleDeclineReturnsValues(t *testing.T) {
+	gateway := newTestGateway(t, func(r url.Values) url.Values {
+		return url.Values{"RESULT": {"12"}, "PNREF": {"A10A0B4E8D8C"}, "RESPMSG": {"Declined"}, "TRANSTIME": {"yesterday"}}
+	})
+
+	response, err := gateway.DoSale(payflow.PayPalCreditCard{PAN: Visa1, Amount: "3.50", ExpDate: "1230"})
+	assert.IsType(t, &payflow.PayPalError{}, err)
+	assert.Equal(t, 12, response.Result)
+	assert.Equal(t, "A10A0B4E8D8C", response.PNREF)
+}
+
+func TestDoSaleTransportErrorReturnsNoValues(t *testing.T) {
+	gateway := payflow.NewClient("user", "password", "partner", "vendor", true)
+	gateway.Endpoint = "http://127.0.0.1:0"
+
+	response, err := gateway.DoSale(payflow.PayPalCreditCard{PAN: Visa1, Amount: "3.50", ExpDate: "1230"})
+	assert.Error(t, err)
+	assert.Nil(t, response)
+}
@@ -0,0 +1,185 @@
+package payflow
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// These constants are the longest values processors accept for the commercial card fields
+const (
+	MaxPONumberLength      = 25
+	MaxUPCLength           = 12
+	MaxDescriptionLength   = 35
+	MaxCommodityCodeLength = 12
+	MaxUnitOfMeasureLength = 12
+	MaxQuantityLength      = 10
+	MaxLineItems           = 99
+)
+
+// PurchaseCardData carries the Level 2 and Level 3 data that qualifies commercial (purchasing) card transactions for
+// lower interchange rates. Level 2 is the PO number and tax information, Level 3 the freight and duty amounts and the line items.
+// All amounts share the format of the transaction amount.
+type PurchaseCardData struct {
+	PONumber      string             `json:"poNumber"`
+	TaxAmount     string             `json:"taxAmount"`
+	TaxExempt     bool               `json:"taxExempt"`
+	FreightAmount string             `json:"freightAmount,omitempty"`
+	DutyAmount    string             `json:"dutyAmount,omitempty"`
+	LineItems     []PurchaseLineItem `json:"lineItems,omitempty"`
+}
+
+// PurchaseLineItem is a Level 3 line item. UnitCost is the cost of a single unit and Amount the total of the line
+type PurchaseLineItem struct {
+	UPC           string `json:"upc,omitempty"`
+	Description   string `json:"description"`
+	CommodityCode string `json:"commodityCode"`
+	Quantity      int    `json:"quantity"`
+	UnitOfMeasure string `json:"unitOfMeasure"`
+	UnitCost      string `json:"unitCost"`
+	TaxAmount     string `json:"taxAmount,omitempty"`
+	Amount        string `json:"amount"`
+}
+
+// PayPalCapture is composed of the data required to capture an authorization (delayed capture).
+// Amount may be left empty to capture the full amount that was authorized. Currency has to be the one of the authorization.
+type PayPalCapture struct {
+	PNREF    string              `json:"pnref"`
+	Amount   string              `json:"amount,omitempty"`
+	Currency Currency            `json:"currency,omitempty"`
+	Purchase *PurchaseCardData   `json:"purchase,omitempty"`
+	Metadata TransactionMetadata `json:"metadata"`
+}
+
+// checkLength returns an error when value is longer than max characters
+func checkLength(field, value string, max int) error {
+	if len(value) > max {
+		return fmt.Errorf("payflow: %s is longer than %d characters", field, max)
+	}
+	return nil
+}
+
+// checkAmount returns an error when a non empty amount is not a valid amount in the currency
+func checkAmount(field, amount string, currency Currency) error {
+	if len(amount) == 0 {
+		return nil
+	}
+	if _, err := parseAmount(amount, currency); err != nil {
+		return fmt.Errorf("payflow: %s: %v", field, err)
+	}
+	return nil
+}
+
+// Validate checks the commercial card data against the field lengths processors accept before it is sent to Payflow.
+// Amounts are checked in the default currency of the merchant account, transactions check them in their own currency
+func (p PurchaseCardData) Validate() error {
+	return p.validate("")
+}
+
+func (p PurchaseCardData) validate(currency Currency) error {
+	if err := checkLength("PONUM", p.PONumber, MaxPONumberLength); err != nil {
+		return err
+	}
+	for _, amount := range [][2]string{{"TAXAMT", p.TaxAmount}, {"FREIGHTAMT", p.FreightAmount}, {"DUTYAMT", p.DutyAmount}} {
+		if err := checkAmount(amount[0], amount[1], currency); err != nil {
+			return err
+		}
+	}
+	if len(p.LineItems) > MaxLineItems {
+		return fmt.Errorf("payflow: at most %d line items can be sent", MaxLineItems)
+	}
+
+	for i, item := range p.LineItems {
+		n := strconv.Itoa(i + 1)
+		if err := checkLength("L_UPC"+n, item.UPC, MaxUPCLength); err != nil {
+			return err
+		}
+		if err := checkLength("L_DESC"+n, item.Description, MaxDescriptionLength); err != nil {
+			return err
+		}
+		if err := checkLength("L_COMMCODE"+n, item.CommodityCode, MaxCommodityCodeLength); err != nil {
+			return err
+		}
+		if err := checkLength("L_UOM"+n, item.UnitOfMeasure, MaxUnitOfMeasureLength); err != nil {
+			return err
+		}
+		if item.Quantity <= 0 {
+			return fmt.Errorf("payflow: L_QTY%s must be positive", n)
+		}
+		if err := checkLength("L_QTY"+n, strconv.Itoa(item.Quantity), MaxQuantityLength); err != nil {
+			return err
+		}
+		for _, amount := range [][2]string{{"L_COST", item.UnitCost}, {"L_TAXAMT", item.TaxAmount}, {"L_AMT", item.Amount}} {
+			if err := checkAmount(amount[0]+n, amount[1], currency); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// apply adds the commercial card data to the request values. Line items are numbered from 1
+func (p PurchaseCardData) apply(values url.Values) {
+	setIfPresent := func(key, value string) {
+		if len(value) != 0 {
+			values.Set(key, value)
+		}
+	}
+
+	setIfPresent("PONUM", p.PONumber)
+	setIfPresent("TAXAMT", p.TaxAmount)
+	if p.TaxExempt {
+		values.Set("TAXEXEMPT", "Y")
+	} else {
+		values.Set("TAXEXEMPT", "N")
+	}
+	setIfPresent("FREIGHTAMT", p.FreightAmount)
+	setIfPresent("DUTYAMT", p.DutyAmount)
+
+	for i, item := range p.LineItems {
+		n := strconv.Itoa(i + 1)
+		setIfPresent("L_UPC"+n, item.UPC)
+		setIfPresent("L_DESC"+n, item.Description)
+		setIfPresent("L_COMMCODE"+n, item.CommodityCode)
+		values.Set("L_QTY"+n, strconv.Itoa(item.Quantity))
+		setIfPresent("L_UOM"+n, item.UnitOfMeasure)
+		setIfPresent("L_COST"+n, item.UnitCost)
+		setIfPresent("L_TAXAMT"+n, item.TaxAmount)
+		setIfPresent("L_AMT"+n, item.Amount)
+	}
+}
+
+// DoCapture captures an authorization (TRXTYPE=D) identified by the PNREF the gateway returned for it.
+// Commercial card data sent with the capture replaces the data sent with the authorization
+func (pClient *PayPalClient) DoCapture(c PayPalCapture) (*PayPalValues, error) {
+	if err := pClient.validateCapture(c); err != nil {
+		return nil, err
+	}
+	values := url.Values{}
+	values.Set("TRXTYPE", "D")
+	values.Set("TENDER", "C")
+	values.Set("ORIGID", c.PNREF)
+	if len(c.Amount) != 0 {
+		values.Set("AMT", c.Amount)
+	}
+	applyCurrency(values, c.Currency)
+	if c.Purchase != nil {
+		c.Purchase.apply(values)
+	}
+	c.Metadata.apply(values)
+
+	return pClient.transact(values)
+}
+
+// validateCapture checks everything about the capture that can be checked before it is sent
+func (pClient *PayPalClient) validateCapture(c PayPalCapture) error {
+	if err := pClient.validateAmount(c.Amount, c.Currency); err != nil {
+		return err
+	}
+	if c.Purchase != nil {
+		if err := c.Purchase.validate(c.Currency); err != nil {
+			return err
+		}
+	}
+	return c.Metadata.Validate()
+}
@@ -0,0 +1,83 @@
+package payflow_test
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/japhy-team/paypal/payflow"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func samplePurchaseCardData() *payflow.PurchaseCardData {
+	return &payflow.PurchaseCardData{
+		PONumber:      "PO-2026-0042",
+		TaxAmount:     "8.25",
+		FreightAmount: "12.00",
+		LineItems: []payflow.PurchaseLineItem{{
+			UPC:           "036000291452",
+			Description:   "Toner cartridge",
+			CommodityCode: "44103103",
+			Quantity:      3,
+			UnitOfMeasure: "EA",
+			UnitCost:      "26.58",
+			Amount:        "79.75",
+		}},
+	}
+}
+
+func TestDoAuthWithPurchaseCardData(t *testing.T) {
+	var request url.Values
+	gateway := newTestGateway(t, func(r url.Values) url.Values {
+		request = r
+		return url.Values{"RESULT": {"0"}, "PNREF": {"A70A0D9EF6A1"}}
+	})
+
+	card := payflow.PayPalCreditCard{PAN: AmericanExpressCorporate, Amount: "100.00", ExpDate: "1230", Purchase: samplePurchaseCardData()}
+	_, err := gateway.DoAuth(card, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "PO-2026-0042", request.Get("PONUM"))
+	assert.Equal(t, "8.25", request.Get("TAXAMT"))
+	assert.Equal(t, "N", request.Get("TAXEXEMPT"))
+	assert.Equal(t, "12.00", request.Get("FREIGHTAMT"))
+	assert.Equal(t, "036000291452", request.Get("L_UPC1"))
+	assert.Equal(t, "3", request.Get("L_QTY1"))
+	assert.Equal(t, "26.58", request.Get("L_COST1"))
+	assert.Equal(t, "44103103", request.Get("L_COMMCODE1"))
+}
+
+func TestDoCaptureWithPurchaseCardData(t *testing.T) {
+	var request url.Values
+	gateway := newTestGateway(t, func(r url.Values) url.Values {
+		request = r
+		return url.Values{"RESULT": {"0"}, "PNREF": {"A70A0D9EF6A2"}}
+	})
+
+	response, err := gateway.DoCapture(payflow.PayPalCapture{PNREF: "A70A0D9EF6A1", Amount: "100.00", Purchase: samplePurchaseCardData()})
+	assert.NoError(t, err)
+	assert.Equal(t, "A70A0D9EF6A2", response.PNREF)
+	assert.Equal(t, "D", request.Get("TRXTYPE"))
+	assert.Equal(t, "A70A0D9EF6A1", request.Get("ORIGID"))
+	assert.Equal(t, "Toner cartridge", request.Get("L_DESC1"))
+}
+
+func TestPurchaseCardDataValidate(t *testing.T) {
+	assert.NoError(t, samplePurchaseCardData().Validate())
+
+	longPONumber := samplePurchaseCardData()
+	longPONumber.PONumber = strings.Repeat("9", payflow.MaxPONumberLength+1)
+	assert.EqualError(t, longPONumber.Validate(), "payflow: PONUM is longer than 25 characters")
+
+	longCommodityCode := samplePurchaseCardData()
+	longCommodityCode.LineItems[0].CommodityCode = "4410310344103103"
+	assert.EqualError(t, longCommodityCode.Validate(), "payflow: L_COMMCODE1 is longer than 12 characters")
+
+	badCost := samplePurchaseCardData()
+	badCost.LineItems[0].UnitCost = "26.589"
+	assert.Error(t, badCost.Validate())
+
+	noQuantity := samplePurchaseCardData()
+	noQuantity.LineItems[0].Quantity = 0
+	assert.Error(t, noQuantity.Validate())
+}
@@ -0,0 +1,386 @@
+// Package reporting is a client for the Payflow XML Reporting API, which runs the reports of the Payflow manager
+// (daily activity, settlements, transaction summaries) and returns their rows.
+package reporting
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// These constants specify the URL that the library hits
+const (
+	ReportingSandboxURL    = "https://payments-reports.paypal.com/test-reportingengine"
+	ReportingProductionURL = "https://payments-reports.paypal.com/reportingengine"
+)
+
+// These constants are the names of the reports the client knows the columns of. Other report names can be run as
+// well, their rows only carry the raw Columns
+const (
+	DailyActivityReport      = "DailyActivityReport"
+	SettlementReport         = "SettlementReport"
+	TransactionSummaryReport = "TransactionSummaryReport"
+)
+
+// DateLayout is the layout of the date parameters reports take, such as report_date, start_date and end_date
+const DateLayout = "2006-01-02 15:04:05"
+
+// ResponseCodeSuccess is the response code of every request the Reporting API completed
+const ResponseCodeSuccess = "100"
+
+// ReportStatus is the progress of a report that was asked to run
+type ReportStatus int
+
+// These constants are the statuses a report goes through
+const (
+	ReportCreated   ReportStatus = 1
+	ReportExecuting ReportStatus = 2
+	ReportCompleted ReportStatus = 3
+	ReportFailed    ReportStatus = 4
+	ReportExpired   ReportStatus = 5
+	ReportPending   ReportStatus = 6
+)
+
+// Done tells whether the report stopped running, successfully or not
+func (s ReportStatus) Done() bool {
+	return s == ReportCompleted || s == ReportFailed || s == ReportExpired
+}
+
+// Client is the type you should use for your Payflow Reporting API Requests.
+// PollInterval is how long WaitForReport waits between two status checks and PollTimeout how long it waits at most.
+// PageSize is the number of rows of each page of a report.
+type Client struct {
+	Username     string
+	Password     string
+	Vendor       string
+	Partner      string
+	Endpoint     string
+	UsesSandbox  bool
+	Client       *http.Client
+	PollInterval time.Duration
+	PollTimeout  time.Duration
+	PageSize     int
+}
+
+// Error is returned when the Reporting API answers with a response code other than 100
+type Error struct {
+	ErrorCode    string
+	ErrorMessage string
+}
+
+func (e *Error) Error() string {
+	return "Payflow Reporting API Call failed. Response Code: " + e.ErrorCode + " Response Message: " + e.ErrorMessage
+}
+
+// ReportError is returned when a report failed or expired before its rows could be fetched
+type ReportError struct {
+	Report *Report
+}
+
+func (e *ReportError) Error() string {
+	return fmt.Sprintf("payflow: report %s did not complete (status %d: %s)", e.Report.ID, e.Report.Status, e.Report.StatusMessage)
+}
+
+// ErrPollTimeout is returned by WaitForReport when the report is still running after PollTimeout
+var ErrPollTimeout = errors.New("payflow: timed out waiting for the report to complete")
+
+// NewClient is a required method call before any API calls are made. Username, Password, Partner, Vendor are the
+// same values the payflow client uses.
+func NewClient(username, password, partner, vendor string, usesSandbox bool) *Client {
+	endpoint := ReportingProductionURL
+	if usesSandbox {
+		endpoint = ReportingSandboxURL
+	}
+
+	return &Client{
+		Username:     username,
+		Password:     password,
+		Partner:      partner,
+		Vendor:       vendor,
+		Endpoint:     endpoint,
+		UsesSandbox:  usesSandbox,
+		Client:       new(http.Client),
+		PollInterval: 5 * time.Second,
+		PollTimeout:  5 * time.Minute,
+		PageSize:     50,
+	}
+}
+
+// Report is a report that was asked to run
+type Report struct {
+	ID            string
+	Name          string
+	Status        ReportStatus
+	StatusMessage string
+}
+
+// Column describes one column of a report
+type Column struct {
+	Number int
+	Name   string
+	Type   string
+}
+
+// MetaData describes the rows of a completed report
+type MetaData struct {
+	ReportName    string
+	NumberOfRows  int
+	NumberOfPages int
+	PageSize      int
+	Columns       []Column
+}
+
+type engineRequest struct {
+	XMLName     xml.Name          `xml:"reportingEngineRequest"`
+	AuthRequest authRequest       `xml:"authRequest"`
+	RunReport   *runReportRequest `xml:"runReportRequest,omitempty"`
+	GetResults  *reportIDRequest  `xml:"getResultsRequest,omitempty"`
+	GetMetaData *reportIDRequest  `xml:"getMetaDataRequest,omitempty"`
+	GetData     *getDataRequest   `xml:"getDataRequest,omitempty"`
+}
+
+type authRequest struct {
+	User     string `xml:"user"`
+	Vendor   string `xml:"vendor"`
+	Partner  string `xml:"partner"`
+	Password string `xml:"password"`
+}
+
+type runReportRequest struct {
+	ReportName string        `xml:"reportName"`
+	Params     []reportParam `xml:"reportParam"`
+	PageSize   int           `xml:"pageSize,omitempty"`
+}
+
+type reportParam struct {
+	Name  string `xml:"paramName"`
+	Value string `xml:"paramValue"`
+}
+
+type reportIDRequest struct {
+	ReportID string `xml:"reportId"`
+}
+
+type getDataRequest struct {
+	ReportID string `xml:"reportId"`
+	PageNum  int    `xml:"pageNum"`
+}
+
+type engineResponse struct {
+	XMLName      xml.Name `xml:"reportingEngineResponse"`
+	ResponseCode string   `xml:"baseResponse>responseCode"`
+	ResponseMsg  string   `xml:"baseResponse>responseMsg"`
+	RunReport    *struct {
+		reportStatus
+	} `xml:"runReportResponse"`
+	GetResults *struct {
+		Results reportStatus `xml:"Results"`
+	} `xml:"getResultsResponse"`
+	GetMetaData *struct {
+		ReportName      string `xml:"reportName"`
+		NumberOfRows    int    `xml:"numberOfRows"`
+		NumberOfPages   int    `xml:"numberOfPages"`
+		PageSize        int    `xml:"pageSize"`
+		ColumnMetaDatas []struct {
+			Number   int    `xml:"colNum,attr"`
+			DataName string `xml:"dataName"`
+			DataType string `xml:"dataType"`
+		} `xml:"columnMetaData"`
+	} `xml:"getMetaDataResponse"`
+	GetData *struct {
+		Rows []struct {
+			Number  int `xml:"rowNum,attr"`
+			Columns []struct {
+				Number int    `xml:"colNum,attr"`
+				Data   string `xml:"data"`
+			} `xml:"columnData"`
+		} `xml:"reportDataRow"`
+	} `xml:"getDataResponse"`
+}
+
+type reportStatus struct {
+	ReportID   string       `xml:"reportId"`
+	StatusCode ReportStatus `xml:"statusCode"`
+	StatusMsg  string       `xml:"statusMsg"`
+}
+
+func (c *Client) performRequest(request engineRequest) (*engineResponse, error) {
+	request.AuthRequest = authRequest{
+		User:     c.Username,
+		Vendor:   c.Vendor,
+		Partner:  c.Partner,
+		Password: c.Password,
+	}
+	document, err := xml.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	httpResponse, err := c.Client.Post(c.Endpoint, "text/plain", bytes.NewReader(append([]byte(xml.Header), document...)))
+	if err != nil {
+		return nil, err
+	}
+	defer httpResponse.Body.Close()
+
+	body, err := ioutil.ReadAll(httpResponse.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &engineResponse{}
+	if err := xml.Unmarshal(body, response); err != nil {
+		return nil, err
+	}
+	if response.ResponseCode != ResponseCodeSuccess {
+		return response, &Error{ErrorCode: response.ResponseCode, ErrorMessage: response.ResponseMsg}
+	}
+	return response, nil
+}
+
+// RunReport asks for the report called name to be run with params, such as report_date for a DailyActivityReport
+// or start_date and end_date for a SettlementReport. Dates are formatted with DateLayout.
+// The report usually has to be waited for before its rows can be fetched.
+func (c *Client) RunReport(name string, params map[string]string) (*Report, error) {
+	run := &runReportRequest{ReportName: name, PageSize: c.PageSize}
+	for paramName, value := range params {
+		run.Params = append(run.Params, reportParam{Name: paramName, Value: value})
+	}
+	sort.Slice(run.Params, func(i, j int) bool { return run.Params[i].Name < run.Params[j].Name })
+
+	response, err := c.performRequest(engineRequest{RunReport: run})
+	if err != nil {
+		return nil, err
+	}
+	if response.RunReport == nil {
+		return nil, errors.New("payflow: the run report response holds no report")
+	}
+	return response.RunReport.report(name), nil
+}
+
+// ReportResults returns the current status of the report
+func (c *Client) ReportResults(report *Report) (*Report, error) {
+	response, err := c.performRequest(engineRequest{GetResults: &reportIDRequest{ReportID: report.ID}})
+	if err != nil {
+		return nil, err
+	}
+	if response.GetResults == nil {
+		return nil, errors.New("payflow: the get results response holds no report")
+	}
+	return response.GetResults.Results.report(report.Name), nil
+}
+
+// WaitForReport polls the status of the report every PollInterval until it completed. A report that failed or
+// expired is returned with a *ReportError, one still running after PollTimeout with ErrPollTimeout.
+func (c *Client) WaitForReport(report *Report) (*Report, error) {
+	deadline := time.Now().Add(c.PollTimeout)
+	for !report.Status.Done() {
+		if time.Now().After(deadline) {
+			return report, ErrPollTimeout
+		}
+		time.Sleep(c.PollInterval)
+
+		var err error
+		if report, err = c.ReportResults(report); err != nil {
+			return nil, err
+		}
+	}
+	if report.Status != ReportCompleted {
+		return report, &ReportError{Report: report}
+	}
+	return report, nil
+}
+
+// ReportMetaData returns the columns and the number of pages of a completed report
+func (c *Client) ReportMetaData(report *Report) (*MetaData, error) {
+	response, err := c.performRequest(engineRequest{GetMetaData: &reportIDRequest{ReportID: report.ID}})
+	if err != nil {
+		return nil, err
+	}
+	if response.GetMetaData == nil {
+		return nil, errors.New("payflow: the get meta data response holds no meta data")
+	}
+
+	metaData := &MetaData{
+		ReportName:    response.GetMetaData.ReportName,
+		NumberOfRows:  response.GetMetaData.NumberOfRows,
+		NumberOfPages: response.GetMetaData.NumberOfPages,
+		PageSize:      response.GetMetaData.PageSize,
+	}
+	for _, column := range response.GetMetaData.ColumnMetaDatas {
+		metaData.Columns = append(metaData.Columns, Column{Number: column.Number, Name: column.DataName, Type: column.DataType})
+	}
+	return metaData, nil
+}
+
+// ReportPage returns the rows of one page of a completed report. Pages are numbered from 1
+func (c *Client) ReportPage(report *Report, metaData *MetaData, page int) ([]Row, error) {
+	response, err := c.performRequest(engineRequest{GetData: &getDataRequest{ReportID: report.ID, PageNum: page}})
+	if err != nil {
+		return nil, err
+	}
+	if response.GetData == nil {
+		return nil, errors.New("payflow: the get data response holds no data")
+	}
+
+	names := map[int]string{}
+	for _, column := range metaData.Columns {
+		names[column.Number] = column.Name
+	}
+
+	rows := make([]Row, 0, len(response.GetData.Rows))
+	for _, dataRow := range response.GetData.Rows {
+		columns := map[string]string{}
+		for _, column := range dataRow.Columns {
+			name, ok := names[column.Number]
+			if !ok {
+				return nil, fmt.Errorf("payflow: row %d has data for unknown column %d", dataRow.Number, column.Number)
+			}
+			columns[name] = column.Data
+		}
+		row, err := newRow(dataRow.Number, columns)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Run runs the report called name with params, waits for it to complete and returns the rows of every page
+func (c *Client) Run(name string, params map[string]string) ([]Row, error) {
+	report, err := c.RunReport(name, params)
+	if err != nil {
+		return nil, err
+	}
+	if report, err = c.WaitForReport(report); err != nil {
+		return nil, err
+	}
+	metaData, err := c.ReportMetaData(report)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []Row
+	for page := 1; page <= metaData.NumberOfPages; page++ {
+		pageRows, err := c.ReportPage(report, metaData, page)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, pageRows...)
+	}
+	return rows, nil
+}
+
+func (s reportStatus) report(name string) *Report {
+	return &Report{
+		ID:            s.ReportID,
+		Name:          name,
+		Status:        s.StatusCode,
+		StatusMessage: s.StatusMsg,
+	}
+}
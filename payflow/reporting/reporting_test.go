@@ -0,0 +1,171 @@
+package reporting_test
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/japhy-team/paypal/payflow/reporting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testRequest is what the stand-in reporting engine reads from the requests it receives
+type testRequest struct {
+	User      string `xml:"authRequest>user"`
+	Password  string `xml:"authRequest>password"`
+	RunReport *struct {
+		ReportName string `xml:"reportName"`
+		Params     []struct {
+			Name  string `xml:"paramName"`
+			Value string `xml:"paramValue"`
+		} `xml:"reportParam"`
+		PageSize int `xml:"pageSize"`
+	} `xml:"runReportRequest"`
+	GetResults  *struct{} `xml:"getResultsRequest"`
+	GetMetaData *struct{} `xml:"getMetaDataRequest"`
+	GetData     *struct {
+		PageNum int `xml:"pageNum"`
+	} `xml:"getDataRequest"`
+}
+
+// newTestEngine starts a stand-in reporting engine answering each request with the body respond returns,
+// wrapped in a successful reportingEngineResponse
+func newTestEngine(t *testing.T, respond func(request testRequest) string) *reporting.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var request testRequest
+		if err := xml.Unmarshal(body, &request); err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(w, "<reportingEngineResponse>%s</reportingEngineResponse>", respond(request))
+	}))
+	t.Cleanup(server.Close)
+
+	client := reporting.NewClient("user", "password", "partner", "vendor", true)
+	client.Endpoint = server.URL
+	client.PollInterval = time.Millisecond
+	return client
+}
+
+const success = "<baseResponse><responseCode>100</responseCode><responseMsg>Request has completed successfully</responseMsg></baseResponse>"
+
+func TestRunSettlementReport(t *testing.T) {
+	var requests []testRequest
+	polls := 0
+	client := newTestEngine(t, func(request testRequest) string {
+		requests = append(requests, request)
+		switch {
+		case request.RunReport != nil:
+			return success + "<runReportResponse><reportId>RE0000000001</reportId><statusCode>1</statusCode><statusMsg>Report has been created</statusMsg></runReportResponse>"
+		case request.GetResults != nil:
+			polls++
+			if polls == 1 {
+				return success + "<getResultsResponse><Results><reportId>RE0000000001</reportId><statusCode>2</statusCode><statusMsg>Report is currently executing</statusMsg></Results></getResultsResponse>"
+			}
+			return success + "<getResultsResponse><Results><reportId>RE0000000001</reportId><statusCode>3</statusCode><statusMsg>Report has completed successfully</statusMsg></Results></getResultsResponse>"
+		case request.GetMetaData != nil:
+			return success + `<getMetaDataResponse><reportName>SettlementReport</reportName><numberOfRows>3</numberOfRows><numberOfPages>2</numberOfPages><pageSize>2</pageSize><numberOfColumns>4</numberOfColumns>
+				<columnMetaData colNum="1"><dataName>Transaction ID</dataName><dataType>string</dataType></columnMetaData>
+				<columnMetaData colNum="2"><dataName>Type</dataName><dataType>string</dataType></columnMetaData>
+				<columnMetaData colNum="3"><dataName>Amount</dataName><dataType>currency</dataType></columnMetaData>
+				<columnMetaData colNum="4"><dataName>Settlement Date</dataName><dataType>date</dataType></columnMetaData>
+				</getMetaDataResponse>`
+		default:
+			if request.GetData.PageNum == 1 {
+				return success + `<getDataResponse>
+					<reportDataRow rowNum="1"><columnData colNum="1"><data>V19A2E235A30</data></columnData><columnData colNum="2"><data>Sale</data></columnData><columnData colNum="3"><data>1050</data></columnData><columnData colNum="4"><data>2020-06-02 00:00:00</data></columnData></reportDataRow>
+					<reportDataRow rowNum="2"><columnData colNum="1"><data>V19A2E235A31</data></columnData><columnData colNum="2"><data>Delayed Capture</data></columnData><columnData colNum="3"><data>300</data></columnData><columnData colNum="4"><data>2020-06-02 00:00:00</data></columnData></reportDataRow>
+					</getDataResponse>`
+			}
+			return success + `<getDataResponse>
+				<reportDataRow rowNum="3"><columnData colNum="1"><data>V19A2E235A32</data></columnData><columnData colNum="2"><data>Credit</data></columnData><columnData colNum="3"><data>-1050</data></columnData><columnData colNum="4"><data>2020-06-03 00:00:00</data></columnData></reportDataRow>
+				</getDataResponse>`
+		}
+	})
+	client.PageSize = 2
+
+	rows, err := client.Run(reporting.SettlementReport, map[string]string{
+		"start_date": "2020-06-01 00:00:00",
+		"end_date":   "2020-06-03 23:59:59",
+	})
+	assert.NoError(t, err)
+
+	run := requests[0]
+	assert.Equal(t, "user", run.User)
+	assert.Equal(t, "password", run.Password)
+	assert.Equal(t, "SettlementReport", run.RunReport.ReportName)
+	assert.Equal(t, 2, run.RunReport.PageSize)
+	assert.Equal(t, "end_date", run.RunReport.Params[0].Name)
+	assert.Equal(t, "2020-06-01 00:00:00", run.RunReport.Params[1].Value)
+	assert.Equal(t, 2, polls)
+
+	if assert.Len(t, rows, 3) {
+		assert.Equal(t, "V19A2E235A30", rows[0].PNREF)
+		assert.Equal(t, int64(1050), rows[0].Amount)
+		assert.Equal(t, time.Date(2020, 6, 2, 0, 0, 0, 0, time.UTC), rows[0].SettlementDate)
+		assert.Equal(t, "Delayed Capture", rows[1].Type)
+		assert.Equal(t, 3, rows[2].Number)
+		assert.Equal(t, int64(-1050), rows[2].Amount)
+		assert.Equal(t, "-1050", rows[2].Columns["Amount"])
+	}
+}
+
+func TestRunReportFailures(t *testing.T) {
+	client := newTestEngine(t, func(request testRequest) string {
+		return "<baseResponse><responseCode>104</responseCode><responseMsg>Invalid report name</responseMsg></baseResponse>"
+	})
+	_, err := client.Run("UnknownReport", nil)
+	assert.EqualError(t, err, "Payflow Reporting API Call failed. Response Code: 104 Response Message: Invalid report name")
+
+	client = newTestEngine(t, func(request testRequest) string {
+		if request.RunReport != nil {
+			return success + "<runReportResponse><reportId>RE0000000002</reportId><statusCode>1</statusCode><statusMsg>Report has been created</statusMsg></runReportResponse>"
+		}
+		return success + "<getResultsResponse><Results><reportId>RE0000000002</reportId><statusCode>4</statusCode><statusMsg>Report has failed</statusMsg></Results></getResultsResponse>"
+	})
+	_, err = client.Run(reporting.DailyActivityReport, map[string]string{"report_date": "2020-06-02"})
+	if assert.IsType(t, &reporting.ReportError{}, err) {
+		assert.Equal(t, reporting.ReportFailed, err.(*reporting.ReportError).Report.Status)
+	}
+}
+
+func TestWaitForReportTimesOut(t *testing.T) {
+	client := newTestEngine(t, func(request testRequest) string {
+		return success + "<getResultsResponse><Results><reportId>RE0000000003</reportId><statusCode>6</statusCode><statusMsg>Report is pending</statusMsg></Results></getResultsResponse>"
+	})
+	client.PollTimeout = 10 * time.Millisecond
+
+	_, err := client.WaitForReport(&reporting.Report{ID: "RE0000000003", Status: reporting.ReportCreated})
+	assert.Equal(t, reporting.ErrPollTimeout, err)
+}
+
+func TestReportPageAmounts(t *testing.T) {
+	var data string
+	client := newTestEngine(t, func(request testRequest) string {
+		return success + `<getDataResponse><reportDataRow rowNum="1"><columnData colNum="1"><data>` + data + `</data></columnData></reportDataRow></getDataResponse>`
+	})
+	report := &reporting.Report{ID: "RE0000000004", Status: reporting.ReportCompleted}
+	metaData := &reporting.MetaData{Columns: []reporting.Column{{Number: 1, Name: reporting.ColumnAmount}}}
+
+	for value, expected := range map[string]int64{"1050": 1050, "-1050": -1050, "10": 10, "": 0} {
+		data = value
+		rows, err := client.ReportPage(report, metaData, 1)
+		if assert.NoError(t, err, value) && assert.Len(t, rows, 1, value) {
+			assert.Equal(t, expected, rows[0].Amount, value)
+		}
+	}
+	// Amounts are in cents, a decimal amount cannot be told apart from a misread one
+	for _, value := range []string{"10.0", "10.50", "--5", "+5", "ten"} {
+		data = value
+		_, err := client.ReportPage(report, metaData, 1)
+		assert.Error(t, err, value)
+	}
+}
@@ -0,0 +1,89 @@
+package reporting
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/japhy-team/paypal/internal/amount"
+)
+
+// These constants are the names of the report columns Row reads its typed fields from
+const (
+	ColumnTransactionID  = "Transaction ID"
+	ColumnTime           = "Time"
+	ColumnType           = "Type"
+	ColumnTenderType     = "Tender Type"
+	ColumnAmount         = "Amount"
+	ColumnResultCode     = "Result Code"
+	ColumnSettlementDate = "Settlement Date"
+)
+
+// timeLayouts are the layouts report times and dates come in
+var timeLayouts = []string{DateLayout, "2006-01-02", "01/02/2006 15:04:05", "01/02/2006"}
+
+// Row is one row of a report. The columns most reports share are parsed into the typed fields, which are left to
+// their zero value when the report has no such column. Columns holds every column of the row as reported.
+type Row struct {
+	Number         int
+	PNREF          string
+	Time           time.Time
+	Type           string
+	TenderType     string
+	Amount         int64 // in cents, as reports give it: "1050" is 10.50 and credits are negative
+	ResultCode     string
+	SettlementDate time.Time
+	Columns        map[string]string
+}
+
+func newRow(number int, columns map[string]string) (Row, error) {
+	row := Row{
+		Number:     number,
+		PNREF:      columns[ColumnTransactionID],
+		Type:       columns[ColumnType],
+		TenderType: columns[ColumnTenderType],
+		ResultCode: columns[ColumnResultCode],
+		Columns:    columns,
+	}
+
+	var err error
+	if row.Time, err = parseTime(columns[ColumnTime]); err != nil {
+		return Row{}, fmt.Errorf("payflow: row %d: %v", number, err)
+	}
+	if row.SettlementDate, err = parseTime(columns[ColumnSettlementDate]); err != nil {
+		return Row{}, fmt.Errorf("payflow: row %d: %v", number, err)
+	}
+	if row.Amount, err = parseAmount(columns[ColumnAmount]); err != nil {
+		return Row{}, fmt.Errorf("payflow: row %d: %v", number, err)
+	}
+	return row, nil
+}
+
+// parseTime parses a report time or date. An empty value is the zero time
+func parseTime(value string) (time.Time, error) {
+	if len(value) == 0 {
+		return time.Time{}, nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", value)
+}
+
+// parseAmount parses a report amount into Row.Amount. An empty value is 0
+func parseAmount(value string) (int64, error) {
+	if len(value) == 0 {
+		return 0, nil
+	}
+	cents, ok := amount.Parse(strings.TrimPrefix(value, "-"), 0)
+	if !ok {
+		return 0, errors.New(amount.Invalid(value, ""))
+	}
+	if strings.HasPrefix(value, "-") {
+		cents = -cents
+	}
+	return cents, nil
+}
@@ -0,0 +1,144 @@
+package payflow
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// AuthenticationStatus is the outcome of the 3-D Secure authentication of the cardholder as reported by the MPI or 3DS server
+type AuthenticationStatus string
+
+// These constants are the 3-D Secure authentication statuses
+const (
+	AuthenticationSuccessful  AuthenticationStatus = "Y" // The cardholder was authenticated
+	AuthenticationAttempted   AuthenticationStatus = "A" // Authentication was attempted but the issuer or cardholder is not enrolled
+	AuthenticationFailed      AuthenticationStatus = "N" // The cardholder failed authentication
+	AuthenticationUnavailable AuthenticationStatus = "U" // Authentication could not be performed
+	AuthenticationRejected    AuthenticationStatus = "R" // The issuer rejected the authentication (3-D Secure 2 only)
+)
+
+// ThreeDSecure carries the result of a 3-D Secure authentication performed by an external MPI or 3DS server.
+// XID is only used by 3-D Secure 1 and DSTransactionID by 3-D Secure 2; Version is the version used, such as "1.0.2" or "2.2.0".
+type ThreeDSecure struct {
+	Status          AuthenticationStatus `json:"authenticationStatus"`
+	CAVV            string               `json:"cavv"`
+	ECI             string               `json:"eci"`
+	XID             string               `json:"xid,omitempty"`
+	DSTransactionID string               `json:"dsTransactionId,omitempty"`
+	Version         string               `json:"version"`
+}
+
+// ThreeDSecureResult is the liability shift outcome of a card transaction that carried 3-D Secure data.
+// LiabilityShift reports whether fraud chargeback liability moved to the issuer, Attempted whether it did so on an
+// attempted rather than a full authentication.
+type ThreeDSecureResult struct {
+	Status         AuthenticationStatus `json:"authenticationStatus"`
+	ECI            string               `json:"eci"`
+	LiabilityShift bool                 `json:"liabilityShift"`
+	Attempted      bool                 `json:"attempted"`
+}
+
+// majorVersion returns 1 or 2 for the 3-D Secure version, 0 when it is not one Payflow supports
+func (s ThreeDSecure) majorVersion() int {
+	switch {
+	case strings.HasPrefix(s.Version, "1."):
+		return 1
+	case strings.HasPrefix(s.Version, "2."):
+		return 2
+	default:
+		return 0
+	}
+}
+
+// Validate checks that the authentication data is complete and consistent before it is sent to Payflow
+func (s ThreeDSecure) Validate() error {
+	version := s.majorVersion()
+	if version == 0 {
+		return fmt.Errorf("payflow: unsupported 3-D Secure version %q", s.Version)
+	}
+	if len(s.ECI) != 2 || s.ECI < "00" || s.ECI > "07" {
+		return fmt.Errorf("payflow: invalid 3-D Secure ECI %q", s.ECI)
+	}
+
+	switch s.Status {
+	case AuthenticationSuccessful, AuthenticationAttempted:
+		if len(s.CAVV) == 0 {
+			return errors.New("payflow: a CAVV is required when 3-D Secure authentication succeeded or was attempted")
+		}
+		if version == 1 && len(s.XID) == 0 {
+			return errors.New("payflow: an XID is required for 3-D Secure 1 authentications")
+		}
+		if version == 2 && len(s.DSTransactionID) == 0 {
+			return errors.New("payflow: a DSTransactionID is required for 3-D Secure 2 authentications")
+		}
+	case AuthenticationFailed, AuthenticationUnavailable:
+		if len(s.CAVV) != 0 {
+			return fmt.Errorf("payflow: a CAVV cannot accompany the 3-D Secure status %q", s.Status)
+		}
+	case AuthenticationRejected:
+		if version != 2 {
+			return errors.New("payflow: the 3-D Secure status R only exists in 3-D Secure 2")
+		}
+		if len(s.CAVV) != 0 {
+			return fmt.Errorf("payflow: a CAVV cannot accompany the 3-D Secure status %q", s.Status)
+		}
+	default:
+		return fmt.Errorf("payflow: unknown 3-D Secure authentication status %q", s.Status)
+	}
+
+	shift, attempted := liabilityShift(s.ECI)
+	switch {
+	case s.Status == AuthenticationSuccessful && (!shift || attempted),
+		s.Status == AuthenticationAttempted && !attempted,
+		s.Status != AuthenticationSuccessful && s.Status != AuthenticationAttempted && shift:
+		return fmt.Errorf("payflow: ECI %s does not match the 3-D Secure status %q", s.ECI, s.Status)
+	}
+	return nil
+}
+
+// liabilityShift interprets the ECI. Visa, American Express and Discover use 05 and 06 for full and attempted
+// authentications, Mastercard uses 02 and 01. Any other value means liability stays with the merchant
+func liabilityShift(eci string) (shift, attempted bool) {
+	switch eci {
+	case "05", "02":
+		return true, false
+	case "06", "01":
+		return true, true
+	default:
+		return false, false
+	}
+}
+
+// apply adds the authentication data to the request values
+func (s ThreeDSecure) apply(values url.Values) {
+	values.Set("AUTHENTICATION_STATUS", string(s.Status))
+	values.Set("ECI", s.ECI)
+	values.Set("THREEDSVERSION", s.Version)
+	if len(s.CAVV) != 0 {
+		values.Set("CAVV", s.CAVV)
+	}
+	if len(s.XID) != 0 {
+		values.Set("XID", s.XID)
+	}
+	if len(s.DSTransactionID) != 0 {
+		values.Set("DSTRANSACTIONID", s.DSTransactionID)
+	}
+}
+
+// result builds the liability shift outcome of the transaction. The status and ECI the gateway returned take
+// precedence over the ones that were sent since the processor may have downgraded them
+func (s ThreeDSecure) result(v *PayPalValues) *ThreeDSecureResult {
+	result := &ThreeDSecureResult{Status: s.Status, ECI: s.ECI}
+	if len(v.AuthenticationStatus) != 0 {
+		result.Status = AuthenticationStatus(v.AuthenticationStatus)
+	}
+	if len(v.ECI) != 0 {
+		result.ECI = v.ECI
+	}
+	if result.Status == AuthenticationSuccessful || result.Status == AuthenticationAttempted {
+		result.LiabilityShift, result.Attempted = liabilityShift(result.ECI)
+	}
+	return result
+}
@@ -0,0 +1,87 @@
+package payflow_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/japhy-team/paypal/payflow"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sampleThreeDSecure() *payflow.ThreeDSecure {
+	return &payflow.ThreeDSecure{
+		Status:          payflow.AuthenticationSuccessful,
+		CAVV:            "AAABBEg0VhI0VniQEjRWAAAAAAA=",
+		ECI:             "05",
+		DSTransactionID: "f25084f0-5b16-4c0a-ae5d-b24808a95e4b",
+		Version:         "2.2.0",
+	}
+}
+
+func TestThreeDSecureValidate(t *testing.T) {
+	assert.NoError(t, sampleThreeDSecure().Validate())
+
+	missingDSTransactionID := sampleThreeDSecure()
+	missingDSTransactionID.DSTransactionID = ""
+	assert.Error(t, missingDSTransactionID.Validate())
+
+	missingXID := sampleThreeDSecure()
+	missingXID.Version = "1.0.2"
+	assert.Error(t, missingXID.Validate())
+
+	inconsistentECI := sampleThreeDSecure()
+	inconsistentECI.ECI = "07"
+	assert.Error(t, inconsistentECI.Validate())
+
+	failedWithCAVV := sampleThreeDSecure()
+	failedWithCAVV.Status = payflow.AuthenticationFailed
+	failedWithCAVV.ECI = "07"
+	assert.Error(t, failedWithCAVV.Validate())
+
+	failedWithCAVV.CAVV = ""
+	assert.NoError(t, failedWithCAVV.Validate())
+}
+
+func TestDoSaleWithThreeDSecure(t *testing.T) {
+	var request url.Values
+	gateway := newTestGateway(t, func(r url.Values) url.Values {
+		request = r
+		return url.Values{"RESULT": {"0"}, "PNREF": {"A10A0B4E8D8A"}}
+	})
+
+	card := payflow.PayPalCreditCard{PAN: Visa1, Amount: "3.50", ExpDate: "1230", ThreeDSecure: sampleThreeDSecure()}
+	response, err := gateway.DoSale(card)
+	assert.NoError(t, err)
+	assert.Equal(t, "Y", request.Get("AUTHENTICATION_STATUS"))
+	assert.Equal(t, "05", request.Get("ECI"))
+	assert.Equal(t, "2.2.0", request.Get("THREEDSVERSION"))
+	assert.Equal(t, card.ThreeDSecure.CAVV, request.Get("CAVV"))
+	assert.Equal(t, card.ThreeDSecure.DSTransactionID, request.Get("DSTRANSACTIONID"))
+	assert.Equal(t, &payflow.ThreeDSecureResult{Status: payflow.AuthenticationSuccessful, ECI: "05", LiabilityShift: true}, response.ThreeDSecure)
+}
+
+func TestDoAuthThreeDSecureDowngradedByProcessor(t *testing.T) {
+	gateway := newTestGateway(t, func(r url.Values) url.Values {
+		return url.Values{"RESULT": {"0"}, "PNREF": {"A10A0B4E8D8B"}, "AUTHENTICATION_STATUS": {"A"}, "ECI": {"06"}}
+	})
+
+	card := payflow.PayPalCreditCard{PAN: Visa1, Amount: "3.50", ExpDate: "1230", ThreeDSecure: sampleThreeDSecure()}
+	response, err := gateway.DoAuth(card, false)
+	assert.NoError(t, err)
+	assert.True(t, response.ThreeDSecure.LiabilityShift)
+	assert.True(t, response.ThreeDSecure.Attempted)
+}
+
+func TestDoSaleRejectsInconsistentThreeDSecure(t *testing.T) {
+	gateway := newTestGateway(t, func(r url.Values) url.Values {
+		t.Errorf("inconsistent 3-D Secure data was sent to the gateway: %v", r)
+		return url.Values{"RESULT": {"0"}}
+	})
+
+	card := payflow.PayPalCreditCard{PAN: Visa1, Amount: "3.50", ExpDate: "1230", ThreeDSecure: sampleThreeDSecure()}
+	card.ThreeDSecure.CAVV = ""
+	response, err := gateway.DoSale(card)
+	assert.Error(t, err)
+	assert.Nil(t, response)
+}
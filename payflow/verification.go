@@ -0,0 +1,53 @@
+package payflow
+
+// CheckResult is the outcome of an address (AVS) or card security code (CVV2) check performed by the issuer
+type CheckResult string
+
+// These constants are the check outcomes Payflow reports
+const (
+	CheckMatched      CheckResult = "Y"
+	CheckNotMatched   CheckResult = "N"
+	CheckNotAvailable CheckResult = "X" // The issuer does not support the check or did not perform it
+	CheckNotSent      CheckResult = ""  // No data was sent for the check
+)
+
+// VerificationResult is the outcome of a zero amount account verification.
+// Approved means the issuer approved the verification (RESULT=0), while Verified additionally requires the card
+// security code not to have been rejected. Address checks are left to the caller since partial matches are common.
+// PNREF can be used as ORIGID for later reference transactions.
+type VerificationResult struct {
+	*PayPalValues
+	Approved     bool        `json:"approved"`
+	Verified     bool        `json:"verified"`
+	AddressCheck CheckResult `json:"addressCheck"`
+	ZipCheck     CheckResult `json:"zipCheck"`
+	CVV2Check    CheckResult `json:"cvv2Check"`
+}
+
+// VerifyCard checks that the card is valid and in good standing without charging it, using an account verification:
+// an authorization with an amount of zero (TRXTYPE=A, AMT=0). c.Amount is ignored.
+// Send c.CVV2 and the billing address for the issuer to check them.
+// Processors that do not support account verification answer with RESULT=4 (invalid amount).
+func (pClient *PayPalClient) VerifyCard(c PayPalCreditCard) (*VerificationResult, error) {
+	c.Amount = formatAmount(0, c.Currency)
+	c.Purchase = nil
+	values := cardValues("A", c)
+	values.Set("VERBOSITY", "HIGH")
+
+	res, err := pClient.cardTransact(values, c)
+	if res == nil {
+		return nil, err
+	}
+
+	result := &VerificationResult{
+		PayPalValues: res,
+		Approved:     res.Result == 0,
+		AddressCheck: CheckResult(res.AVSAddress),
+		ZipCheck:     CheckResult(res.AVSZipcode),
+	}
+	if res.CVV2Match != 0 {
+		result.CVV2Check = CheckResult(res.CVV2Match)
+	}
+	result.Verified = result.Approved && result.CVV2Check != CheckNotMatched
+	return result, err
+}
@@ -0,0 +1,63 @@
+package payflow_test
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/japhy-team/paypal/payflow"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyCard(t *testing.T) {
+	var request url.Values
+	gateway := newTestGateway(t, func(r url.Values) url.Values {
+		request = r
+		return url.Values{"RESULT": {"0"}, "PNREF": {"A11A0B6E3CC9"}, "AVSADDR": {"Y"}, "AVSZIP": {"N"}, "CVV2MATCH": {"Y"}}
+	})
+
+	card := payflow.PayPalCreditCard{PAN: Visa1, Amount: "25.00", ExpDate: "1230", CVV2: "123", BillToStreet: "123 Main St", BillToZip: "95131"}
+	result, err := gateway.VerifyCard(card)
+	assert.NoError(t, err)
+	assert.True(t, result.Approved)
+	assert.True(t, result.Verified)
+	assert.Equal(t, "A11A0B6E3CC9", result.PNREF)
+	assert.Equal(t, payflow.CheckMatched, result.AddressCheck)
+	assert.Equal(t, payflow.CheckNotMatched, result.ZipCheck)
+	assert.Equal(t, payflow.CheckMatched, result.CVV2Check)
+	assert.Equal(t, "A", request.Get("TRXTYPE"))
+	assert.Equal(t, "0.00", request.Get("AMT"))
+	assert.Equal(t, "123", request.Get("CVV2"))
+	assert.Equal(t, "95131", request.Get("BILLTOZIP"))
+	assert.NotContains(t, fmt.Sprintf("%+v", card), "123,")
+}
+
+func TestVerifyCardWithRejectedSecurityCode(t *testing.T) {
+	gateway := newTestGateway(t, func(r url.Values) url.Values {
+		return url.Values{"RESULT": {"0"}, "PNREF": {"A11A0B6E3CCA"}, "CVV2MATCH": {"N"}}
+	})
+
+	result, err := gateway.VerifyCard(payflow.PayPalCreditCard{PAN: Visa1, ExpDate: "1230", CVV2: "999"})
+	assert.NoError(t, err)
+	assert.True(t, result.Approved)
+	assert.False(t, result.Verified)
+
+	_, _, err = payflow.NewCardOnFile(gateway, payflow.NewMemoryTokenRepository()).Store("customer-42", payflow.PayPalCreditCard{PAN: Visa1, ExpDate: "1230", CVV2: "999"})
+	assert.Error(t, err)
+}
+
+func TestCardOnFileStoresVerifiedCards(t *testing.T) {
+	var request url.Values
+	gateway := newTestGateway(t, func(r url.Values) url.Values {
+		request = r
+		return url.Values{"RESULT": {"0"}, "PNREF": {"A11A0B6E3CCB"}, "CVV2MATCH": {"Y"}, "CCTRANSID": {"VIS000000001"}}
+	})
+
+	stored, _, err := payflow.NewCardOnFile(gateway, payflow.NewMemoryTokenRepository()).Store("customer-42", payflow.PayPalCreditCard{PAN: Visa1, ExpDate: "1230", CVV2: "123"})
+	assert.NoError(t, err)
+	assert.Equal(t, "A11A0B6E3CCB", stored.PNREF)
+	assert.Equal(t, "VIS000000001", stored.NetworkTransactionID)
+	assert.Equal(t, "0.00", request.Get("AMT"))
+	assert.Equal(t, "CITI", request.Get("CARDONFILE"))
+}
@@ -0,0 +1,409 @@
+package payflow
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// XMLPayNamespace is the namespace of XMLPay 2.0 documents
+const XMLPayNamespace = "http://www.paypal.com/XMLPay"
+
+// UnsupportedProtocolError is returned by the transactions of a tender the client's Protocol cannot carry, which are
+// the ACH and Express Checkout tenders over XMLPay. Nothing is sent to Payflow
+type UnsupportedProtocolError struct {
+	Tender string
+}
+
+func (e *UnsupportedProtocolError) Error() string {
+	return fmt.Sprintf("payflow: TENDER=%s transactions are not supported over XMLPay", e.Tender)
+}
+
+// checkTender returns an UnsupportedProtocolError when the client's Protocol cannot carry transactions of the tender
+func (pClient *PayPalClient) checkTender(tender string) error {
+	if pClient.Protocol == ProtocolXMLPay && tender != "C" {
+		return &UnsupportedProtocolError{Tender: tender}
+	}
+	return nil
+}
+
+// xmlPayRequest is an XMLPay 2.0 request document holding a single transaction
+type xmlPayRequest struct {
+	XMLName     xml.Name          `xml:"XMLPayRequest"`
+	Namespace   string            `xml:"xmlns,attr"`
+	Version     string            `xml:"version,attr"`
+	Timeout     int               `xml:"Timeout,attr"`
+	RequestData xmlPayRequestData `xml:"RequestData"`
+	RequestAuth xmlPayRequestAuth `xml:"RequestAuth"`
+}
+
+type xmlPayRequestData struct {
+	Vendor       string
+	Partner      string
+	Transactions []xmlPayTransaction `xml:"Transactions>Transaction"`
+}
+
+type xmlPayRequestAuth struct {
+	User     string `xml:"UserPass>User"`
+	Password string `xml:"UserPass>Password"`
+}
+
+// xmlPayTransaction holds exactly one of the operations
+type xmlPayTransaction struct {
+	Sale          *xmlPayPayment   `xml:",omitempty"`
+	Authorization *xmlPayPayment   `xml:",omitempty"`
+	Capture       *xmlPayReference `xml:",omitempty"`
+	Void          *xmlPayReference `xml:",omitempty"`
+	Credit        *xmlPayReference `xml:",omitempty"`
+	GetStatus     *xmlPayReference `xml:",omitempty"`
+}
+
+type xmlPayPayment struct {
+	PayData xmlPayData
+	ExtData []xmlPayExtData `xml:"ExtData"`
+}
+
+type xmlPayData struct {
+	Invoice xmlPayInvoice
+	Tender  xmlPayTender
+}
+
+type xmlPayInvoice struct {
+	InvNum      string        `xml:",omitempty"`
+	BillTo      *xmlPayBillTo `xml:",omitempty"`
+	Description string        `xml:",omitempty"`
+	PONum       string        `xml:",omitempty"`
+	TaxExempt   string        `xml:",omitempty"`
+	TaxAmt      string        `xml:",omitempty"`
+	FreightAmt  string        `xml:",omitempty"`
+	DutyAmt     string        `xml:",omitempty"`
+	Items       *xmlPayItems  `xml:",omitempty"`
+	TotalAmt    *xmlPayAmount `xml:",omitempty"`
+	Comment     string        `xml:",omitempty"`
+	CustRef     string        `xml:",omitempty"`
+}
+
+type xmlPayBillTo struct {
+	Street string `xml:"Address>Street,omitempty"`
+	Zip    string `xml:"Address>Zip,omitempty"`
+}
+
+type xmlPayItems struct {
+	Item []xmlPayItem
+}
+
+type xmlPayItem struct {
+	Number        int    `xml:"Number,attr"`
+	UPC           string `xml:",omitempty"`
+	Description   string `xml:",omitempty"`
+	Quantity      string `xml:",omitempty"`
+	UnitOfMeasure string `xml:",omitempty"`
+	UnitPrice     string `xml:",omitempty"`
+	TaxAmt        string `xml:",omitempty"`
+	TotalAmt      string `xml:",omitempty"`
+	CommCode      string `xml:",omitempty"`
+}
+
+type xmlPayAmount struct {
+	Currency string `xml:"Currency,attr,omitempty"`
+	Value    string `xml:",chardata"`
+}
+
+type xmlPayTender struct {
+	Card *xmlPayCard `xml:",omitempty"`
+}
+
+type xmlPayCard struct {
+	CardNum string `xml:",omitempty"`
+	ExpDate string `xml:",omitempty"`
+	CVNum   string `xml:",omitempty"`
+	MagData string `xml:",omitempty"`
+}
+
+type xmlPayReference struct {
+	PNRef   string          `xml:",omitempty"`
+	Invoice *xmlPayInvoice  `xml:",omitempty"`
+	ExtData []xmlPayExtData `xml:"ExtData"`
+}
+
+// xmlPayExtData carries the parameters that have no element of their own
+type xmlPayExtData struct {
+	Name  string `xml:"Name,attr"`
+	Value string `xml:"Value,attr"`
+}
+
+// xmlPayResponse is an XMLPay 2.0 response document
+type xmlPayResponse struct {
+	XMLName xml.Name       `xml:"XMLPayResponse"`
+	Results []xmlPayResult `xml:"ResponseData>TransactionResults>TransactionResult"`
+}
+
+type xmlPayResult struct {
+	Result      string
+	Message     string
+	PNRef       string
+	AuthCode    string
+	HostCode    string
+	OrigResult  string
+	OrigPNRef   string
+	TransState  string
+	Duplicate   string
+	StreetMatch string `xml:"AVSResult>StreetMatch"`
+	ZipMatch    string `xml:"AVSResult>ZipMatch"`
+	CVResult    string
+	ExtData     []xmlPayExtData `xml:"ExtData"`
+}
+
+// xmlPayRequestValues consumes the name-value pairs of a request as they are mapped to XMLPay elements.
+// Whatever is left once the document is built is sent as ExtData
+type xmlPayRequestValues url.Values
+
+func (v xmlPayRequestValues) take(key string) string {
+	value := url.Values(v).Get(key)
+	delete(v, key)
+	return value
+}
+
+// invoice builds the invoice from the amounts, the commercial card data and the metadata of the request.
+// COMMENT2 and ORDERID have no invoice element and are sent as ExtData
+func (v xmlPayRequestValues) invoice() *xmlPayInvoice {
+	invoice := &xmlPayInvoice{
+		InvNum:      v.take("INVNUM"),
+		Description: v.take("ORDERDESC"),
+		PONum:       v.take("PONUM"),
+		TaxAmt:      v.take("TAXAMT"),
+		FreightAmt:  v.take("FREIGHTAMT"),
+		DutyAmt:     v.take("DUTYAMT"),
+		Comment:     v.take("COMMENT1"),
+		CustRef:     v.take("CUSTREF"),
+	}
+	switch v.take("TAXEXEMPT") {
+	case "Y":
+		invoice.TaxExempt = "true"
+	case "N":
+		invoice.TaxExempt = "false"
+	}
+	if street, zip := v.take("BILLTOSTREET"), v.take("BILLTOZIP"); len(street) != 0 || len(zip) != 0 {
+		invoice.BillTo = &xmlPayBillTo{Street: street, Zip: zip}
+	}
+	if amount, currency := v.take("AMT"), v.take("CURRENCY"); len(amount) != 0 {
+		invoice.TotalAmt = &xmlPayAmount{Currency: currency, Value: amount}
+	} else if len(currency) != 0 {
+		v["CURRENCY"] = []string{currency}
+	}
+
+	for i := 1; len(url.Values(v)["L_QTY"+strconv.Itoa(i)]) != 0; i++ {
+		n := strconv.Itoa(i)
+		if invoice.Items == nil {
+			invoice.Items = &xmlPayItems{}
+		}
+		invoice.Items.Item = append(invoice.Items.Item, xmlPayItem{
+			Number:        i,
+			UPC:           v.take("L_UPC" + n),
+			Description:   v.take("L_DESC" + n),
+			Quantity:      v.take("L_QTY" + n),
+			UnitOfMeasure: v.take("L_UOM" + n),
+			UnitPrice:     v.take("L_COST" + n),
+			TaxAmt:        v.take("L_TAXAMT" + n),
+			TotalAmt:      v.take("L_AMT" + n),
+			CommCode:      v.take("L_COMMCODE" + n),
+		})
+	}
+
+	if invoice.BillTo == nil && invoice.TotalAmt == nil && invoice.Items == nil &&
+		len(invoice.InvNum+invoice.Description+invoice.PONum+invoice.TaxExempt+invoice.TaxAmt+
+			invoice.FreightAmt+invoice.DutyAmt+invoice.Comment+invoice.CustRef) == 0 {
+		return nil
+	}
+	return invoice
+}
+
+// extData returns the parameters that were not mapped to an element, sorted by name
+func (v xmlPayRequestValues) extData() []xmlPayExtData {
+	var extData []xmlPayExtData
+	for name := range v {
+		extData = append(extData, xmlPayExtData{Name: name, Value: url.Values(v).Get(name)})
+	}
+	sort.Slice(extData, func(i, j int) bool { return extData[i].Name < extData[j].Name })
+	return extData
+}
+
+// encodeXMLPay converts the name-value pairs of a request into an XMLPay 2.0 document
+func encodeXMLPay(values url.Values) ([]byte, error) {
+	v := xmlPayRequestValues{}
+	for key, value := range values {
+		v[key] = value
+	}
+
+	request := xmlPayRequest{
+		Namespace: XMLPayNamespace,
+		Version:   "2.0",
+		Timeout:   30,
+		RequestData: xmlPayRequestData{
+			Vendor:  v.take("VENDOR"),
+			Partner: v.take("PARTNER"),
+		},
+		RequestAuth: xmlPayRequestAuth{
+			User:     v.take("USER"),
+			Password: v.take("PWD"),
+		},
+	}
+
+	if tender := v.take("TENDER"); tender != "C" {
+		return nil, &UnsupportedProtocolError{Tender: tender}
+	}
+
+	var transaction xmlPayTransaction
+	switch trxType := v.take("TRXTYPE"); trxType {
+	case "S", "A":
+		payment := &xmlPayPayment{}
+		if invoice := v.invoice(); invoice != nil {
+			payment.PayData.Invoice = *invoice
+		}
+		payment.PayData.Tender.Card = &xmlPayCard{
+			CardNum: v.take("ACCT"),
+			ExpDate: xmlPayExpDate(v.take("EXPDATE")),
+			CVNum:   v.take("CVV2"),
+			MagData: v.take("SWIPE"),
+		}
+		payment.ExtData = v.extData()
+		if trxType == "S" {
+			transaction.Sale = payment
+		} else {
+			transaction.Authorization = payment
+		}
+	case "D", "V", "C", "I":
+		reference := &xmlPayReference{PNRef: v.take("ORIGID")}
+		reference.Invoice = v.invoice()
+		reference.ExtData = v.extData()
+		switch trxType {
+		case "D":
+			transaction.Capture = reference
+		case "V":
+			transaction.Void = reference
+		case "C":
+			transaction.Credit = reference
+		default:
+			transaction.GetStatus = reference
+		}
+	default:
+		return nil, fmt.Errorf("payflow: TRXTYPE=%s transactions are not supported over XMLPay", trxType)
+	}
+	request.RequestData.Transactions = []xmlPayTransaction{transaction}
+
+	document, err := xml.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), document...), nil
+}
+
+// xmlPayExpDate converts an MMYY expiration date into the YYYYMM format XMLPay uses
+func xmlPayExpDate(expDate string) string {
+	if len(expDate) != 4 {
+		return expDate
+	}
+	return "20" + expDate[2:] + expDate[:2]
+}
+
+// xmlPayMatch converts an XMLPay AVS or CVV2 outcome into the single letter name-value pairs use
+func xmlPayMatch(match string) string {
+	switch strings.ToLower(match) {
+	case "":
+		return ""
+	case "match", "y":
+		return "Y"
+	case "no match", "n":
+		return "N"
+	default:
+		return "X"
+	}
+}
+
+// xmlPayDuplicate converts the Duplicate element of an XMLPay response into the DUPLICATE code name-value pairs use.
+// XMLPay flags a duplicate transaction as true, other codes such as 2 for an ORDERID already processed are kept
+func xmlPayDuplicate(duplicate string) string {
+	switch strings.ToLower(duplicate) {
+	case "true":
+		return "1"
+	case "false":
+		return ""
+	default:
+		return duplicate
+	}
+}
+
+// decodeXMLPay converts an XMLPay 2.0 response document into the name-value pairs the same response would have
+// had, so both protocols share the rest of the response handling
+func decodeXMLPay(document []byte) (url.Values, error) {
+	var response xmlPayResponse
+	if err := xml.Unmarshal(document, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Results) == 0 {
+		return nil, fmt.Errorf("payflow: XMLPay response holds no transaction result")
+	}
+	result := response.Results[0]
+
+	values := url.Values{}
+	for _, field := range [][2]string{
+		{"RESULT", result.Result},
+		{"RESPMSG", result.Message},
+		{"PNREF", result.PNRef},
+		{"AUTHCODE", result.AuthCode},
+		{"HOSTCODE", result.HostCode},
+		{"ORIGRESULT", result.OrigResult},
+		{"ORIGPNREF", result.OrigPNRef},
+		{"TRANSSTATE", result.TransState},
+		{"DUPLICATE", xmlPayDuplicate(result.Duplicate)},
+		{"AVSADDR", xmlPayMatch(result.StreetMatch)},
+		{"AVSZIP", xmlPayMatch(result.ZipMatch)},
+		{"CVV2MATCH", xmlPayMatch(result.CVResult)},
+	} {
+		if len(field[1]) != 0 {
+			values.Set(field[0], field[1])
+		}
+	}
+	for _, extData := range result.ExtData {
+		values.Set(extData.Name, extData.Value)
+	}
+	return values, nil
+}
+
+// postXMLPay sends the request as an XMLPay document and converts the XMLPay response
+func (pClient *PayPalClient) postXMLPay(values url.Values) (url.Values, error) {
+	document, err := encodeXMLPay(values)
+	if err != nil {
+		return nil, err
+	}
+	// Unlike the strings it was built from, the document can be cleared once sent
+	defer func() {
+		for i := range document {
+			document[i] = 0
+		}
+	}()
+
+	request, err := http.NewRequest(http.MethodPost, pClient.Endpoint, bytes.NewReader(document))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "text/xml")
+
+	xmlResponse, err := pClient.Client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer xmlResponse.Body.Close()
+
+	body, err := ioutil.ReadAll(xmlResponse.Body)
+	if err != nil {
+		return nil, err
+	}
+	return decodeXMLPay(body)
+}
@@ -0,0 +1,165 @@
+package payflow_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/japhy-team/paypal/payflow"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const xmlPayApproved = `<?xml version="1.0" encoding="UTF-8"?>
+<XMLPayResponse xmlns="http://www.paypal.com/XMLPay">
+  <ResponseData>
+    <Vendor>vendor</Vendor>
+    <Partner>partner</Partner>
+    <TransactionResults>
+      <TransactionResult>
+        <Result>0</Result>
+        <AVSResult>
+          <StreetMatch>Match</StreetMatch>
+          <ZipMatch>No Match</ZipMatch>
+        </AVSResult>
+        <CVResult>Match</CVResult>
+        <Message>Approved</Message>
+        <PNRef>V19A2E235A30</PNRef>
+        <AuthCode>090PNI</AuthCode>
+        <ExtData Name="CCTRANSID" Value="MCC0000000001"/>
+      </TransactionResult>
+    </TransactionResults>
+  </ResponseData>
+</XMLPayResponse>`
+
+// newTestXMLPayGateway starts a stand-in Payflow gateway that records the XMLPay documents it receives
+// and answers every one of them with response
+func newTestXMLPayGateway(t *testing.T, response string, documents *[]string) *payflow.PayPalClient {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "text/xml", r.Header.Get("Content-Type"))
+		*documents = append(*documents, string(body))
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+
+	testClient := payflow.NewClient("user", "password", "partner", "vendor", true)
+	testClient.Endpoint = server.URL
+	testClient.Protocol = payflow.ProtocolXMLPay
+	return testClient
+}
+
+func TestDoSaleOverXMLPay(t *testing.T) {
+	var documents []string
+	gateway := newTestXMLPayGateway(t, xmlPayApproved, &documents)
+
+	card := payflow.PayPalCreditCard{PAN: Visa1, Amount: "3.50", ExpDate: "1230", CVV2: "123", BillToZip: "95131", CardOnFile: payflow.CardholderInitiatedInitial}
+	response, err := gateway.DoSale(card)
+	assert.NoError(t, err)
+
+	document := documents[0]
+	assert.Contains(t, document, `<XMLPayRequest xmlns="http://www.paypal.com/XMLPay" version="2.0"`)
+	assert.Contains(t, document, "<Vendor>vendor</Vendor><Partner>partner</Partner>")
+	assert.Contains(t, document, "<UserPass><User>user</User><Password>password</Password></UserPass>")
+	assert.Contains(t, document, "<Sale><PayData><Invoice>")
+	assert.Contains(t, document, "<BillTo><Address><Zip>95131</Zip></Address></BillTo>")
+	assert.Contains(t, document, "<TotalAmt>3.50</TotalAmt>")
+	assert.Contains(t, document, "<Card><CardNum>4111111111111111</CardNum><ExpDate>203012</ExpDate><CVNum>123</CVNum></Card>")
+	assert.Contains(t, document, `<ExtData Name="CARDONFILE" Value="CITI"></ExtData>`)
+
+	// The XMLPay response converts into the values the same name-value pairs response would have produced
+	nvpGateway := newTestGateway(t, func(r url.Values) url.Values {
+		return url.Values{
+			"RESULT": {"0"}, "RESPMSG": {"Approved"}, "PNREF": {"V19A2E235A30"}, "AUTHCODE": {"090PNI"},
+			"AVSADDR": {"Y"}, "AVSZIP": {"N"}, "CVV2MATCH": {"Y"}, "CCTRANSID": {"MCC0000000001"},
+		}
+	})
+	nvpResponse, err := nvpGateway.DoSale(card)
+	assert.NoError(t, err)
+	assert.Equal(t, nvpResponse, response)
+}
+
+func TestReferenceTransactionsOverXMLPay(t *testing.T) {
+	var documents []string
+	gateway := newTestXMLPayGateway(t, xmlPayApproved, &documents)
+
+	_, err := gateway.DoCapture(payflow.PayPalCapture{PNREF: "A10A0B4E8D8A", Amount: "3.50"})
+	assert.NoError(t, err)
+	_, err = gateway.DoVoid("A10A0B4E8D8A")
+	assert.NoError(t, err)
+	_, err = gateway.DoCredit(payflow.PayPalCredit{PNREF: "A10A0B4E8D8A"})
+	assert.NoError(t, err)
+	_, err = gateway.DoInquiry("A10A0B4E8D8A")
+	assert.NoError(t, err)
+
+	assert.Contains(t, documents[0], "<Capture><PNRef>A10A0B4E8D8A</PNRef><Invoice><TotalAmt>3.50</TotalAmt></Invoice></Capture>")
+	assert.Contains(t, documents[1], "<Void><PNRef>A10A0B4E8D8A</PNRef></Void>")
+	assert.Contains(t, documents[2], "<Credit><PNRef>A10A0B4E8D8A</PNRef></Credit>")
+	assert.Contains(t, documents[3], `<GetStatus><PNRef>A10A0B4E8D8A</PNRef><ExtData Name="VERBOSITY" Value="HIGH"></ExtData></GetStatus>`)
+}
+
+func TestDeclineOverXMLPay(t *testing.T) {
+	var documents []string
+	gateway := newTestXMLPayGateway(t, `<XMLPayResponse xmlns="http://www.paypal.com/XMLPay"><ResponseData><TransactionResults>
+		<TransactionResult><Result>12</Result><Message>Declined</Message><PNRef>V19A2E235A31</PNRef></TransactionResult>
+		</TransactionResults></ResponseData></XMLPayResponse>`, &documents)
+
+	response, err := gateway.DoAuth(payflow.PayPalCreditCard{PAN: Visa1, Amount: "3.50", ExpDate: "1230"}, false)
+	assert.EqualError(t, err, "Payflow API Call failed. Response Code: 12 Response Message: Declined")
+	assert.Equal(t, 12, response.Result)
+	assert.Contains(t, documents[0], "<Authorization>")
+}
+
+func TestDoSaleWithProcessedOrderIDOverXMLPay(t *testing.T) {
+	var documents []string
+	gateway := newTestXMLPayGateway(t, `<XMLPayResponse xmlns="http://www.paypal.com/XMLPay"><ResponseData><TransactionResults>
+		<TransactionResult><Result>0</Result><Message>Approved</Message><PNRef>A10A0B4E8D91</PNRef><Duplicate>2</Duplicate></TransactionResult>
+		</TransactionResults></ResponseData></XMLPayResponse>`, &documents)
+
+	response, err := gateway.DoSale(payflow.PayPalCreditCard{PAN: Visa1, Amount: "3.50", ExpDate: "1230", Metadata: sampleMetadata})
+	assert.Equal(t, &payflow.DuplicateOrderError{OrderID: "ORDER-1001", PNREF: "A10A0B4E8D91"}, err)
+	assert.Equal(t, "A10A0B4E8D91", response.PNREF)
+	assert.Contains(t, documents[0], `<ExtData Name="ORDERID" Value="ORDER-1001"></ExtData>`)
+}
+
+func TestDuplicateTransactionOverXMLPay(t *testing.T) {
+	var documents []string
+	gateway := newTestXMLPayGateway(t, `<XMLPayResponse xmlns="http://www.paypal.com/XMLPay"><ResponseData><TransactionResults>
+		<TransactionResult><Result>0</Result><Message>Approved</Message><PNRef>A10A0B4E8D93</PNRef><Duplicate>true</Duplicate></TransactionResult>
+		</TransactionResults></ResponseData></XMLPayResponse>`, &documents)
+
+	response, err := gateway.DoSale(payflow.PayPalCreditCard{PAN: Visa1, Amount: "3.50", ExpDate: "1230"})
+	assert.NoError(t, err)
+	assert.Equal(t, "1", response.Duplicate)
+}
+
+func TestDoInquiryByCustomerReferenceOverXMLPay(t *testing.T) {
+	var documents []string
+	gateway := newTestXMLPayGateway(t, `<XMLPayResponse xmlns="http://www.paypal.com/XMLPay"><ResponseData><TransactionResults>
+		<TransactionResult><Result>0</Result><Message>Approved</Message><PNRef>A10A0B4E8D92</PNRef><OrigResult>0</OrigResult>
+		<OrigPNRef>A10A0B4E8D90</OrigPNRef><TransState>6</TransState></TransactionResult>
+		</TransactionResults></ResponseData></XMLPayResponse>`, &documents)
+
+	response, err := gateway.DoInquiryByCustomerReference("CUST-42")
+	assert.NoError(t, err)
+	assert.Contains(t, documents[0], "<GetStatus><Invoice><CustRef>CUST-42</CustRef></Invoice>")
+	assert.Equal(t, "A10A0B4E8D90", response.OriginalPNREF)
+	assert.Equal(t, 0, response.OriginalResult)
+	assert.Equal(t, 6, response.TransactionState)
+}
+
+func TestUnsupportedTenderOverXMLPay(t *testing.T) {
+	var documents []string
+	gateway := newTestXMLPayGateway(t, xmlPayApproved, &documents)
+
+	_, err := gateway.DoACHSale(sampleBankAccount())
+	assert.Equal(t, &payflow.UnsupportedProtocolError{Tender: "A"}, err)
+	_, err = gateway.GetExpressCheckoutDetails(payflow.ExpressCheckoutSale, "EC-TOKEN")
+	assert.Equal(t, &payflow.UnsupportedProtocolError{Tender: "P"}, err)
+	assert.Empty(t, documents)
+}
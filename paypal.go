@@ -33,42 +33,42 @@ type PayPalDigitalGood struct {
 }
 
 type PayPalResponse struct {
-	Ack           string     `json:"Ack"`
+	Ack           Ack        `json:"Ack"`
 	Build         string     `json:"Build"`
 	CorrelationID string     `json:"CorrelationId"`
-	Timestamp     string     `json:"Timestamp"`
+	Timestamp     time.Time  `json:"Timestamp"`
 	Version       string     `json:"Version"`
 	Values        url.Values `json:"Values"`
 	usedSandbox   bool
 }
 
 type PayPalValues struct {
-	Ack                       string `json:"ack,omitempty"`
-	Amount                    string `json:"amt,omitempty"`
-	BillingAgreementID        string `json:"billingagreementid,omitempty"`
-	Build                     string `json:"build,omitempty"`
-	CorrelationID             string `json:"correlationid,omitempty"`
-	CurrencyCode              string `json:"currencycode,omitempty"`
-	ErrorCode                 string `json:"errorcode0,omitempty"`
-	ErrorMessage              string `json:"l_shortmessage0,omitempty"`
-	ErrorMessageExtended      string `json:"l_longmessage0,omitempty"`
-	DateOrdered               string `json:"ordertime,omitempty"`
-	PaymentStatus             string `json:"paymentstatus,omitempty"`
-	PaymentType               string `json:"paymenttype,omitempty"`
-	PendingReason             string `json:"pendingreason,omitempty"`
-	ProtectionEligibility     string `json:"protectioneligiblity,omitempty"`
-	ProtectionEligibilityType string `json:"protectioneligibilitytype,omitempty"`
-	ReasonCode                string `json:"reasoncode,omitempty"`
-	SeverityCode              string `json:"l_severitycode0,omitempty"`
-	TaxedAmount               string `json:"taxamt,omitempty"`
-	Timestamp                 string `json:"timestamp,omitempty"`
-	TransactionID             string `json:"transactionid,omitempty"`
-	TransactionType           string `json:"transactiontype,omitempty"`
-	Version                   string `json:"version,omitempty"`
+	Ack                       Ack           `json:"ack,omitempty"`
+	Amount                    string        `json:"amt,omitempty"`
+	BillingAgreementID        string        `json:"billingagreementid,omitempty"`
+	Build                     string        `json:"build,omitempty"`
+	CorrelationID             string        `json:"correlationid,omitempty"`
+	CurrencyCode              string        `json:"currencycode,omitempty"`
+	ErrorCode                 string        `json:"errorcode0,omitempty"`
+	ErrorMessage              string        `json:"l_shortmessage0,omitempty"`
+	ErrorMessageExtended      string        `json:"l_longmessage0,omitempty"`
+	DateOrdered               time.Time     `json:"ordertime,omitempty"`
+	PaymentStatus             PaymentStatus `json:"paymentstatus,omitempty"`
+	PaymentType               PaymentType   `json:"paymenttype,omitempty"`
+	PendingReason             PendingReason `json:"pendingreason,omitempty"`
+	ProtectionEligibility     string        `json:"protectioneligiblity,omitempty"`
+	ProtectionEligibilityType string        `json:"protectioneligibilitytype,omitempty"`
+	ReasonCode                ReasonCode    `json:"reasoncode,omitempty"`
+	SeverityCode              string        `json:"l_severitycode0,omitempty"`
+	TaxedAmount               string        `json:"taxamt,omitempty"`
+	Timestamp                 time.Time     `json:"timestamp,omitempty"`
+	TransactionID             string        `json:"transactionid,omitempty"`
+	TransactionType           string        `json:"transactiontype,omitempty"`
+	Version                   string        `json:"version,omitempty"`
 }
 
 type PayPalError struct {
-	Ack          string
+	Ack          Ack
 	ErrorCode    string
 	ShortMessage string
 	LongMessage  string
@@ -80,7 +80,7 @@ func (e *PayPalError) Error() string {
 	if len(e.ErrorCode) != 0 && len(e.ShortMessage) != 0 {
 		message = "PayPal Error " + e.ErrorCode + ": " + e.ShortMessage
 	} else if len(e.Ack) != 0 {
-		message = e.Ack
+		message = string(e.Ack)
 	} else {
 		message = "PayPal is undergoing maintenance.\nPlease try again later."
 	}
@@ -88,6 +88,43 @@ func (e *PayPalError) Error() string {
 	return message
 }
 
+// ResponseParseError is returned along with the response when PayPal answered but some of the response values could not
+// be parsed, such as a malformed TIMESTAMP. The values that could not be parsed are left to their zero value.
+// A request that failed is reported with a PayPalError instead
+type ResponseParseError struct {
+	Errors []error
+}
+
+func (e *ResponseParseError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = strings.TrimPrefix(err.Error(), "paypal: ")
+	}
+	return "paypal: invalid response: " + strings.Join(messages, "; ")
+}
+
+// requestFailed reports whether err means the request failed, rather than that PayPal answered with a response that
+// could only be partly parsed
+func requestFailed(err error) bool {
+	_, parseErr := err.(*ResponseParseError)
+	return err != nil && !parseErr
+}
+
+// ValidationError is returned when a request is not sent to PayPal because it would fail. Unlike a PayPalError, it is
+// not an answer from PayPal
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return "paypal: " + e.Message
+}
+
+// newValidationError returns the error of a request that was not sent because it would fail
+func newValidationError(message string) *ValidationError {
+	return &ValidationError{Message: message}
+}
+
 func (r *PayPalResponse) CheckoutUrl() string {
 	query := url.Values{}
 	query.Set("cmd", "_express-checkout")
@@ -169,15 +206,19 @@ func (pClient *PayPalClient) PerformRequest(values url.Values) (*PayPalResponse,
 	responseValues, err := url.ParseQuery(string(body))
 	response := &PayPalResponse{usedSandbox: pClient.usesSandbox}
 	if err == nil {
-		response.Ack = responseValues.Get("ACK")
+		response.Ack = ParseAck(responseValues.Get("ACK"))
 		response.CorrelationID = responseValues.Get("CORRELATIONID")
-		response.Timestamp = responseValues.Get("TIMESTAMP")
+		var parseErrors []error
+		if response.Timestamp, err = parseTimeValue(responseValues, "TIMESTAMP"); err != nil {
+			parseErrors = append(parseErrors, err)
+			err = nil
+		}
 		response.Version = responseValues.Get("VERSION")
 		response.Build = responseValues.Get("2975009")
 		response.Values = responseValues
 
 		errorCode := responseValues.Get("L_ERRORCODE0")
-		if len(errorCode) != 0 || strings.ToLower(response.Ack) == "failure" || strings.ToLower(response.Ack) == "failurewithwarning" {
+		if len(errorCode) != 0 || response.Ack.IsFailure() {
 			pError := new(PayPalError)
 			pError.Ack = response.Ack
 			pError.ErrorCode = errorCode
@@ -186,6 +227,9 @@ func (pClient *PayPalClient) PerformRequest(values url.Values) (*PayPalResponse,
 			pError.SeverityCode = responseValues.Get("L_SEVERITYCODE0")
 
 			err = pError
+		} else if len(parseErrors) != 0 {
+			// PayPal processed the request, the response is returned along with what could not be read of it
+			err = &ResponseParseError{Errors: parseErrors}
 		}
 	}
 
@@ -245,6 +289,8 @@ func (pClient *PayPalClient) GetExpressCheckoutDetails(token string) (*PayPalRes
 // Forked
 //----------------------------------------------------------
 
+// CreateRecurringPaymentsProfile creates a recurring payments profile. Dates in params, such as PROFILESTARTDATE,
+// should be formatted with FormatTime
 func (pClient *PayPalClient) CreateRecurringPaymentsProfile(token string, params map[string]string) (*PayPalResponse, error) {
 	values := url.Values{}
 	values.Add("TOKEN", token)
@@ -316,6 +362,11 @@ func (pClient *PayPalClient) DoReferenceTransaction(paymentAmount string, refere
 // however it can be used to capture any authorized payment
 // See https://developer.paypal.com/docs/classic/api/merchant/DoCapture-API-Operation-NVP/ for details
 func (pClient *PayPalClient) DoCapture(paymentAmount string, authorizationID string, isComplete bool, invoiceID string) (*PayPalResponse, error) {
+	return pClient.PerformRequest(captureValues(paymentAmount, authorizationID, isComplete, invoiceID))
+}
+
+// captureValues returns the request values of DoCapture
+func captureValues(paymentAmount string, authorizationID string, isComplete bool, invoiceID string) url.Values {
 	values := url.Values{}
 	values.Set("METHOD", "DoCapture")
 	values.Add("AMT", paymentAmount)
@@ -326,8 +377,7 @@ func (pClient *PayPalClient) DoCapture(paymentAmount string, authorizationID str
 	} else {
 		values.Add("COMPLETETYPE", "NotComplete")
 	}
-
-	return pClient.PerformRequest(values)
+	return values
 }
 
 // DoVoid voids an authorized payment
@@ -368,8 +418,21 @@ func (pClient *PayPalClient) DoVoid(authorizationID, note, messageID string) (*P
 // According to their docs: Ack, CorrelationID, Timestamp, Version, and Build should be in every response
 // from PayPal so we return the values placed in the root of the PayPalResponse struct instead
 // of checking the url.Values array. Since some values may not be provided in the PayPalResponse struct
-// We have to check the response
+// We have to check the response. Dates are parsed in UTC, those that cannot be parsed are left zero
 func (pClient *PayPalClient) ConvertResponse(paypalResponse PayPalResponse) *PayPalValues {
+	values, _ := pClient.ParseResponse(paypalResponse)
+	return values
+}
+
+// ParseResponse is ConvertResponse, returning a *ResponseParseError along with the values when some of them could not
+// be parsed
+func (pClient *PayPalClient) ParseResponse(paypalResponse PayPalResponse) (*PayPalValues, error) {
+	var err error
+	dateOrdered, parseErr := parseTimeValue(paypalResponse.Values, "ORDERTIME")
+	if parseErr != nil {
+		err = &ResponseParseError{Errors: []error{parseErr}}
+	}
+
 	return &PayPalValues{
 		Ack:                  paypalResponse.Ack,
 		Amount:               pClient.parseResponse(paypalResponse.Values["AMT"]),
@@ -377,19 +440,20 @@ func (pClient *PayPalClient) ConvertResponse(paypalResponse PayPalResponse) *Pay
 		Build:                pClient.parseResponse(paypalResponse.Values["BUILD"]),
 		CorrelationID:        paypalResponse.CorrelationID,
 		CurrencyCode:         pClient.parseResponse(paypalResponse.Values["CURRENCYCODE"]),
-		DateOrdered:          pClient.parseResponse(paypalResponse.Values["ORDERTIME"]),
+		DateOrdered:          dateOrdered,
 		ErrorCode:            pClient.parseResponse(paypalResponse.Values["ERRORCODE0"]),
 		ErrorMessage:         pClient.parseResponse(paypalResponse.Values["L_SHORTMESSAGE0"]),
 		ErrorMessageExtended: pClient.parseResponse(paypalResponse.Values["L_LONGMESSAGE0"]),
 		SeverityCode:         pClient.parseResponse(paypalResponse.Values["L_SEVERITYCODE0"]),
-		PaymentStatus:        pClient.parseResponse(paypalResponse.Values["PAYMENTSTATUS"]),
-		PendingReason:        pClient.parseResponse(paypalResponse.Values["PENDINGREASON"]),
-		ReasonCode:           pClient.parseResponse(paypalResponse.Values["REASONCODE"]),
+		PaymentStatus:        ParsePaymentStatus(pClient.parseResponse(paypalResponse.Values["PAYMENTSTATUS"])),
+		PaymentType:          ParsePaymentType(pClient.parseResponse(paypalResponse.Values["PAYMENTTYPE"])),
+		PendingReason:        ParsePendingReason(pClient.parseResponse(paypalResponse.Values["PENDINGREASON"])),
+		ReasonCode:           ParseReasonCode(pClient.parseResponse(paypalResponse.Values["REASONCODE"])),
 		Timestamp:            paypalResponse.Timestamp,
 		TransactionID:        pClient.parseResponse(paypalResponse.Values["TRANSACTIONID"]),
 		TransactionType:      pClient.parseResponse(paypalResponse.Values["TRANSACTIONTYPE"]),
 		Version:              pClient.parseResponse(paypalResponse.Values["VERSION"]),
-	}
+	}, err
 }
 
 // parseResponse is a helper function for convert response. this simple functionality
@@ -493,7 +557,7 @@ func (pClient *PayPalClient) ProfileTransactionSearch(profileId string, startDat
 
 	values.Set("PROFILEID", profileId)
 	values.Set("METHOD", "TransactionSearch")
-	values.Set("STARTDATE", startDate.Format(time.RFC3339))
+	values.Set("STARTDATE", FormatTime(startDate))
 
 	return pClient.PerformRequest(values)
 }
@@ -1,6 +1,9 @@
 package paypal_test
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"strings"
 	"testing"
@@ -90,3 +93,15 @@ func TestErroneousGetExpressCheckoutDetails(t *testing.T) {
 		t.Errorf("Expected an error during transaction, but got a successful transaction: %#v.", response)
 	}
 }
+
+// newTestClient returns a client sending its requests to a test server that answers them with respond
+func newTestClient(t *testing.T, respond func(url.Values) url.Values) *paypal.PayPalClient {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("Cannot parse the request: %v", err)
+		}
+		w.Write([]byte(respond(r.PostForm).Encode()))
+	}))
+	t.Cleanup(server.Close)
+	return paypal.NewDefaultClientEndpoint("username", "password", "signature", server.URL, true)
+}
@@ -0,0 +1,89 @@
+package paypal
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// RecurringPaymentsProfile is the typed form of the response of GetRecurringPaymentsProfileDetails.
+// Status is one of Active, Pending, Cancelled, Suspended or Expired. Dates are in UTC and zero when PayPal did not
+// send them, such as the NextBillingDate of a cancelled profile
+type RecurringPaymentsProfile struct {
+	ProfileID           string    `json:"profileid,omitempty"`
+	Status              string    `json:"status,omitempty"`
+	Description         string    `json:"desc,omitempty"`
+	SubscriberName      string    `json:"subscribername,omitempty"`
+	ProfileReference    string    `json:"profilereference,omitempty"`
+	ProfileStartDate    time.Time `json:"profilestartdate"`
+	NextBillingDate     time.Time `json:"nextbillingdate"`
+	FinalPaymentDueDate time.Time `json:"finalpaymentduedate"`
+	LastPaymentDate     time.Time `json:"lastpaymentdate"`
+	LastPaymentAmount   string    `json:"lastpaymentamt,omitempty"`
+	Amount              string    `json:"amt,omitempty"`
+	CurrencyCode        string    `json:"currencycode,omitempty"`
+	OutstandingBalance  string    `json:"outstandingbalance,omitempty"`
+	NumCyclesCompleted  int       `json:"numcyclescompleted"`
+	NumCyclesRemaining  int       `json:"numcyclesremaining"`
+	FailedPaymentCount  int       `json:"failedpaymentcount"`
+}
+
+// RecurringPaymentsProfile reads the profile from the response of GetRecurringPaymentsProfileDetails
+func (r *PayPalResponse) RecurringPaymentsProfile() (*RecurringPaymentsProfile, error) {
+	values := r.Values
+	profile := &RecurringPaymentsProfile{
+		ProfileID:          values.Get("PROFILEID"),
+		Status:             values.Get("STATUS"),
+		Description:        values.Get("DESC"),
+		SubscriberName:     values.Get("SUBSCRIBERNAME"),
+		ProfileReference:   values.Get("PROFILEREFERENCE"),
+		LastPaymentAmount:  values.Get("LASTPAYMENTAMT"),
+		Amount:             values.Get("AMT"),
+		CurrencyCode:       values.Get("CURRENCYCODE"),
+		OutstandingBalance: values.Get("OUTSTANDINGBALANCE"),
+	}
+
+	dates := []struct {
+		key  string
+		date *time.Time
+	}{
+		{"PROFILESTARTDATE", &profile.ProfileStartDate},
+		{"NEXTBILLINGDATE", &profile.NextBillingDate},
+		{"FINALPAYMENTDUEDATE", &profile.FinalPaymentDueDate},
+		{"LASTPAYMENTDATE", &profile.LastPaymentDate},
+	}
+	var err error
+	for _, d := range dates {
+		if *d.date, err = parseTimeValue(values, d.key); err != nil {
+			return nil, err
+		}
+	}
+	counts := []struct {
+		key   string
+		count *int
+	}{
+		{"NUMCYCLESCOMPLETED", &profile.NumCyclesCompleted},
+		{"NUMCYCLESREMAINING", &profile.NumCyclesRemaining},
+		{"FAILEDPAYMENTCOUNT", &profile.FailedPaymentCount},
+	}
+	for _, c := range counts {
+		if *c.count, err = parseIntValue(values, c.key); err != nil {
+			return nil, err
+		}
+	}
+	return profile, nil
+}
+
+// parseIntValue parses the number of the response values under key. A missing or empty number is zero
+func parseIntValue(values url.Values, key string) (int, error) {
+	value := values.Get(key)
+	if len(value) == 0 {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("paypal: %s: %v", key, err)
+	}
+	return n, nil
+}
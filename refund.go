@@ -0,0 +1,163 @@
+package paypal
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// RefundType is the type of a refund
+type RefundType string
+
+// These constants are the types of refund
+const (
+	RefundFull            RefundType = "Full"
+	RefundPartial         RefundType = "Partial"
+	RefundExternalDispute RefundType = "ExternalDispute"
+	RefundOther           RefundType = "Other"
+)
+
+// RefundSource is the funding source of a refund
+type RefundSource string
+
+// These constants are the funding sources of refunds. RefundSourceAny uses the PayPal balance first, then the
+// eCheck of the account when the balance does not cover the refund
+const (
+	RefundSourceAny     RefundSource = "any"
+	RefundSourceDefault RefundSource = "default"
+	RefundSourceInstant RefundSource = "instant"
+	RefundSourceEcheck  RefundSource = "eCheck"
+)
+
+// RefundStatus tells whether a refund was processed right away or is waiting on its funding
+type RefundStatus string
+
+// These constants are the statuses of refunds
+const (
+	RefundStatusInstant RefundStatus = "Instant"
+	RefundStatusDelayed RefundStatus = "Delayed"
+	RefundStatusNone    RefundStatus = "None"
+)
+
+// These constants are the longest values PayPal accepts for the fields of a refund
+const (
+	MaxRefundNoteLength      = 255
+	MaxRefundInvoiceIDLength = 127
+	MaxMessageIDLength       = 38
+)
+
+// RefundRequest holds the fields of a RefundTransaction request. Amount and CurrencyCode are required by partial
+// refunds and not sent with full refunds. Items are the items refunded, if any.
+// MessageID (MSGSUBID) makes the request idempotent: PayPal answers a request sent again with the same MessageID
+// with the response of the first one instead of refunding twice
+type RefundRequest struct {
+	TransactionID string
+	Type          RefundType
+	Amount        string
+	CurrencyCode  string
+	Note          string
+	InvoiceID     string
+	RefundSource  RefundSource
+	Items         []TransactionItem
+	MessageID     string
+}
+
+// RefundResult is the typed response of RefundTransaction. GrossRefundAmount is the amount refunded to the buyer,
+// FeeRefundAmount the PayPal fee returned to the merchant and NetRefundAmount what the refund cost the merchant.
+// TotalRefundedAmount is the total refunded from the transaction so far, this refund included
+type RefundResult struct {
+	RefundTransactionID string        `json:"refundtransactionid,omitempty"`
+	FeeRefundAmount     string        `json:"feerefundamt,omitempty"`
+	GrossRefundAmount   string        `json:"grossrefundamt,omitempty"`
+	NetRefundAmount     string        `json:"netrefundamt,omitempty"`
+	TotalRefundedAmount string        `json:"totalrefundedamount,omitempty"`
+	CurrencyCode        string        `json:"currencycode,omitempty"`
+	RefundStatus        RefundStatus  `json:"refundstatus,omitempty"`
+	PendingReason       PendingReason `json:"pendingreason,omitempty"`
+	MessageID           string        `json:"msgsubid,omitempty"`
+	Timestamp           time.Time     `json:"timestamp"`
+}
+
+// Validate checks the refund before it is sent to PayPal
+func (r RefundRequest) Validate() error {
+	switch {
+	case len(r.TransactionID) == 0:
+		return newValidationError("TransactionID is required")
+	case r.Type == RefundPartial && (len(r.Amount) == 0 || len(r.CurrencyCode) == 0):
+		return newValidationError("a partial refund requires an Amount and a CurrencyCode")
+	case r.Type == RefundFull && len(r.Amount) != 0:
+		return newValidationError("a full refund cannot have an Amount")
+	case len(r.Note) > MaxRefundNoteLength:
+		return newValidationError(fmt.Sprintf("Note is longer than %d characters", MaxRefundNoteLength))
+	case len(r.InvoiceID) > MaxRefundInvoiceIDLength:
+		return newValidationError(fmt.Sprintf("InvoiceID is longer than %d characters", MaxRefundInvoiceIDLength))
+	case len(r.MessageID) > MaxMessageIDLength:
+		return newValidationError(fmt.Sprintf("MessageID is longer than %d characters", MaxMessageIDLength))
+	}
+	if len(r.Amount) != 0 {
+		if _, err := parseAmount(r.Amount, r.CurrencyCode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// values returns the request values of the refund
+func (r RefundRequest) values() url.Values {
+	values := url.Values{}
+	values.Set("METHOD", "RefundTransaction")
+	values.Set("TRANSACTIONID", r.TransactionID)
+	refundType := r.Type
+	if len(refundType) == 0 {
+		refundType = RefundFull
+	}
+	values.Set("REFUNDTYPE", string(refundType))
+
+	setIfPresent := func(key, value string) {
+		if len(value) != 0 {
+			values.Set(key, value)
+		}
+	}
+	setIfPresent("AMT", r.Amount)
+	setIfPresent("CURRENCYCODE", r.CurrencyCode)
+	setIfPresent("NOTE", r.Note)
+	setIfPresent("INVOICEID", r.InvoiceID)
+	setIfPresent("REFUNDSOURCE", string(r.RefundSource))
+	setIfPresent("MSGSUBID", r.MessageID)
+	for i, item := range r.Items {
+		n := strconv.Itoa(i)
+		setIfPresent("L_NAME"+n, item.Name)
+		setIfPresent("L_NUMBER"+n, item.Number)
+		if item.Quantity != 0 {
+			values.Set("L_QTY"+n, strconv.Itoa(item.Quantity))
+		}
+		setIfPresent("L_AMT"+n, item.Amount)
+	}
+	return values
+}
+
+// RefundTransaction refunds a transaction in full or in part. A Type left empty is a full refund. The refund went
+// through when the result is returned along with a *ResponseParseError
+// See https://developer.paypal.com/docs/classic/api/merchant/RefundTransaction-API-Operation-NVP/ for details
+func (pClient *PayPalClient) RefundTransaction(r RefundRequest) (*RefundResult, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	response, err := pClient.PerformRequest(r.values())
+	if requestFailed(err) {
+		return nil, err
+	}
+	return &RefundResult{
+		RefundTransactionID: response.Values.Get("REFUNDTRANSACTIONID"),
+		FeeRefundAmount:     response.Values.Get("FEEREFUNDAMT"),
+		GrossRefundAmount:   response.Values.Get("GROSSREFUNDAMT"),
+		NetRefundAmount:     response.Values.Get("NETREFUNDAMT"),
+		TotalRefundedAmount: response.Values.Get("TOTALREFUNDEDAMOUNT"),
+		CurrencyCode:        response.Values.Get("CURRENCYCODE"),
+		RefundStatus:        RefundStatus(response.Values.Get("REFUNDSTATUS")),
+		PendingReason:       ParsePendingReason(response.Values.Get("PENDINGREASON")),
+		MessageID:           response.Values.Get("MSGSUBID"),
+		Timestamp:           response.Timestamp,
+	}, err
+}
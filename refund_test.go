@@ -0,0 +1,94 @@
+package paypal_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/japhy-team/paypal"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefundTransaction(t *testing.T) {
+	var request url.Values
+	client := newTestClient(t, func(r url.Values) url.Values {
+		request = r
+		return url.Values{
+			"ACK":                 {"Success"},
+			"REFUNDTRANSACTIONID": {"REFUND1"},
+			"FEEREFUNDAMT":        {"0.30"},
+			"GROSSREFUNDAMT":      {"10.00"},
+			"NETREFUNDAMT":        {"9.70"},
+			"TOTALREFUNDEDAMOUNT": {"15.00"},
+			"CURRENCYCODE":        {"EUR"},
+			"REFUNDSTATUS":        {"Instant"},
+			"PENDINGREASON":       {"None"},
+			"MSGSUBID":            {"REFUND-ORDER-42-1"},
+		}
+	})
+
+	result, err := client.RefundTransaction(paypal.RefundRequest{
+		TransactionID: "TX1",
+		Type:          paypal.RefundPartial,
+		Amount:        "10.00",
+		CurrencyCode:  "EUR",
+		Note:          "Damaged item",
+		InvoiceID:     "ORDER-42",
+		RefundSource:  paypal.RefundSourceInstant,
+		Items:         []paypal.TransactionItem{{Name: "Mug", Quantity: 1, Amount: "10.00"}},
+		MessageID:     "REFUND-ORDER-42-1",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "RefundTransaction", request.Get("METHOD"))
+	assert.Equal(t, "Partial", request.Get("REFUNDTYPE"))
+	assert.Equal(t, "EUR", request.Get("CURRENCYCODE"))
+	assert.Equal(t, "Damaged item", request.Get("NOTE"))
+	assert.Equal(t, "ORDER-42", request.Get("INVOICEID"))
+	assert.Equal(t, "instant", request.Get("REFUNDSOURCE"))
+	assert.Equal(t, "Mug", request.Get("L_NAME0"))
+	assert.Equal(t, "1", request.Get("L_QTY0"))
+	assert.Equal(t, "REFUND-ORDER-42-1", request.Get("MSGSUBID"))
+
+	assert.Equal(t, "REFUND1", result.RefundTransactionID)
+	assert.Equal(t, "0.30", result.FeeRefundAmount)
+	assert.Equal(t, "10.00", result.GrossRefundAmount)
+	assert.Equal(t, "15.00", result.TotalRefundedAmount)
+	assert.Equal(t, paypal.RefundStatusInstant, result.RefundStatus)
+	assert.Equal(t, paypal.PendingReasonNone, result.PendingReason)
+
+	_, err = client.RefundTransaction(paypal.RefundRequest{TransactionID: "TX1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Full", request.Get("REFUNDTYPE"))
+	assert.NotContains(t, request, "AMT")
+}
+
+func TestRefundTransactionInvalidTimestamp(t *testing.T) {
+	client := newTestClient(t, func(r url.Values) url.Values {
+		return url.Values{"ACK": {"Success"}, "TIMESTAMP": {"not a date"}, "REFUNDTRANSACTIONID": {"REFUND1"}}
+	})
+
+	result, err := client.RefundTransaction(paypal.RefundRequest{TransactionID: "TX1"})
+	assert.IsType(t, &paypal.ResponseParseError{}, err)
+	if assert.NotNil(t, result) {
+		assert.Equal(t, "REFUND1", result.RefundTransactionID)
+	}
+}
+
+func TestRefundTransactionValidation(t *testing.T) {
+	client := newTestClient(t, func(r url.Values) url.Values {
+		t.Error("The refund should not be sent")
+		return url.Values{}
+	})
+
+	for _, refund := range []paypal.RefundRequest{
+		{Type: paypal.RefundFull},
+		{TransactionID: "TX1", Type: paypal.RefundPartial, Amount: "10.00"},
+		{TransactionID: "TX1", Type: paypal.RefundFull, Amount: "10.00"},
+		{TransactionID: "TX1", Type: paypal.RefundPartial, Amount: "10.001", CurrencyCode: "USD"},
+		{TransactionID: "TX1", Type: paypal.RefundPartial, Amount: "10.50", CurrencyCode: "JPY"},
+		{TransactionID: "TX1", MessageID: "0123456789012345678901234567890123456789"},
+	} {
+		_, err := client.RefundTransaction(refund)
+		assert.IsType(t, &paypal.ValidationError{}, err)
+	}
+}
@@ -0,0 +1,297 @@
+package paypal
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// refundExceedsCode is the error code of a refund PayPal rejected because it goes past what remains of the transaction
+const refundExceedsCode = "10009"
+
+// refundSearchWindow is how far back the earlier refunds of a transaction whose order time is unknown are searched.
+// PayPal accepts refunds up to 180 days after a payment
+const refundSearchWindow = 180 * 24 * time.Hour
+
+// RefundBalance is what was captured and refunded so far of a transaction, in CurrencyCode
+type RefundBalance struct {
+	TransactionID  string `json:"transactionid"`
+	CurrencyCode   string `json:"currencycode"`
+	CapturedAmount string `json:"capturedamt"`
+	RefundedAmount string `json:"refundedamt"`
+}
+
+// Remaining returns the amount that can still be refunded
+func (b RefundBalance) Remaining() (string, error) {
+	remaining, err := b.remaining()
+	if err != nil {
+		return "", err
+	}
+	return formatAmount(remaining, b.CurrencyCode), nil
+}
+
+func (b RefundBalance) remaining() (int64, error) {
+	captured, err := parseAmount(b.CapturedAmount, b.CurrencyCode)
+	if err != nil {
+		return 0, err
+	}
+	refunded, err := parseAmount(b.RefundedAmount, b.CurrencyCode)
+	if err != nil {
+		return 0, err
+	}
+	return captured - refunded, nil
+}
+
+// RefundStore keeps the refund balances of a RefundManager by transaction ID. Get returns a nil balance for
+// a transaction it does not know
+type RefundStore interface {
+	Get(transactionID string) (*RefundBalance, error)
+	Save(b *RefundBalance) error
+	Delete(transactionID string) error
+}
+
+// MemoryRefundStore is a RefundStore kept in memory
+type MemoryRefundStore struct {
+	mutex    sync.Mutex
+	balances map[string]RefundBalance
+}
+
+// NewMemoryRefundStore returns an empty MemoryRefundStore
+func NewMemoryRefundStore() *MemoryRefundStore {
+	return &MemoryRefundStore{balances: map[string]RefundBalance{}}
+}
+
+// Get returns a copy of the balance of the transaction, or nil when there is none
+func (s *MemoryRefundStore) Get(transactionID string) (*RefundBalance, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	b, ok := s.balances[transactionID]
+	if !ok {
+		return nil, nil
+	}
+	return &b, nil
+}
+
+// Save stores a copy of the balance
+func (s *MemoryRefundStore) Save(b *RefundBalance) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.balances[b.TransactionID] = *b
+	return nil
+}
+
+// Delete removes the balance of the transaction
+func (s *MemoryRefundStore) Delete(transactionID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.balances, transactionID)
+	return nil
+}
+
+// RefundExceedsBalanceError is returned by RefundManager.Refund for a refund larger than what remains to be refunded
+// of the transaction. The refund is not sent to PayPal
+type RefundExceedsBalanceError struct {
+	TransactionID string
+	Amount        string
+	Remaining     string
+	CurrencyCode  string
+}
+
+func (e *RefundExceedsBalanceError) Error() string {
+	return fmt.Sprintf("paypal: cannot refund %s %s of transaction %s, %s remain", e.Amount, e.CurrencyCode,
+		e.TransactionID, e.Remaining)
+}
+
+// StaleBalanceError is returned by RefundManager.Refund when PayPal rejected a refund as going past the balance and
+// the balance could not be removed from the store, which still holds it. Err is the PayPalError of the refund and
+// StoreErr the error of the store
+type StaleBalanceError struct {
+	TransactionID string
+	Err           error
+	StoreErr      error
+}
+
+func (e *StaleBalanceError) Error() string {
+	return fmt.Sprintf("paypal: %v, and the balance of transaction %s could not be forgotten: %v", e.Err,
+		e.TransactionID, e.StoreErr)
+}
+
+func (e *StaleBalanceError) Unwrap() error {
+	return e.Err
+}
+
+// RefundManager refunds transactions without going past what was captured. It keeps the captured and refunded
+// totals of every transaction it refunds in its Store, and reads them from PayPal the first time it sees a transaction:
+// the captured amount from GetTransactionDetails and, if it was already partially refunded, the earlier refunds from
+// TransactionSearch
+type RefundManager struct {
+	Client *PayPalClient
+	Store  RefundStore
+
+	mutex sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// NewRefundManager returns a RefundManager keeping its balances in store
+func NewRefundManager(client *PayPalClient, store RefundStore) *RefundManager {
+	return &RefundManager{Client: client, Store: store}
+}
+
+// Balance returns the balance of the transaction, read from PayPal and saved when the store does not know it yet
+func (m *RefundManager) Balance(transactionID string) (*RefundBalance, error) {
+	lock := m.lock(transactionID)
+	lock.Lock()
+	defer lock.Unlock()
+	return m.balance(transactionID)
+}
+
+// Refund sends the refund when it does not go past what remains to be refunded of the transaction, and records it.
+// A full refund of a transaction that was partially refunded already is sent as a partial refund of what remains.
+// The currency of the refund defaults to the one of the transaction. When PayPal rejects a refund as going past
+// the balance anyway, the balance is forgotten so that it is read again from PayPal on the next refund, and a
+// *StaleBalanceError is returned when the store fails to forget it
+func (m *RefundManager) Refund(r RefundRequest) (*RefundResult, error) {
+	lock := m.lock(r.TransactionID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	b, err := m.balance(r.TransactionID)
+	if err != nil {
+		return nil, err
+	}
+	if len(r.CurrencyCode) == 0 {
+		r.CurrencyCode = b.CurrencyCode
+	} else if !strings.EqualFold(r.CurrencyCode, b.CurrencyCode) {
+		return nil, newValidationError(fmt.Sprintf("the transaction %s is in %s, not %s", r.TransactionID,
+			b.CurrencyCode, r.CurrencyCode))
+	}
+	remaining, err := b.remaining()
+	if err != nil {
+		return nil, err
+	}
+
+	refunded, err := parseAmount(b.RefundedAmount, b.CurrencyCode)
+	if err != nil {
+		return nil, err
+	}
+	if r.Type == RefundFull || len(r.Type) == 0 {
+		if refunded == 0 {
+			r.Type, r.Amount = RefundFull, ""
+		} else {
+			r.Type, r.Amount = RefundPartial, formatAmount(remaining, b.CurrencyCode)
+		}
+	}
+
+	amount := remaining
+	if len(r.Amount) != 0 {
+		if amount, err = parseAmount(r.Amount, b.CurrencyCode); err != nil {
+			return nil, err
+		}
+	}
+	if amount > remaining || remaining <= 0 {
+		return nil, &RefundExceedsBalanceError{
+			TransactionID: r.TransactionID,
+			Amount:        formatAmount(amount, b.CurrencyCode),
+			Remaining:     formatAmount(remaining, b.CurrencyCode),
+			CurrencyCode:  b.CurrencyCode,
+		}
+	}
+
+	result, err := m.Client.RefundTransaction(r)
+	if requestFailed(err) {
+		if pError, ok := err.(*PayPalError); ok && pError.ErrorCode == refundExceedsCode {
+			if storeErr := m.Store.Delete(r.TransactionID); storeErr != nil {
+				return nil, &StaleBalanceError{TransactionID: r.TransactionID, Err: err, StoreErr: storeErr}
+			}
+		}
+		return nil, err
+	}
+
+	b.RefundedAmount = formatAmount(refunded+amount, b.CurrencyCode)
+	if len(result.TotalRefundedAmount) != 0 {
+		// PayPal knows best, including of refunds made without the manager
+		if _, err := parseAmount(result.TotalRefundedAmount, b.CurrencyCode); err == nil {
+			b.RefundedAmount = result.TotalRefundedAmount
+		}
+	}
+	if saveErr := m.Store.Save(b); saveErr != nil {
+		return result, saveErr
+	}
+	// The refund went through, a *ResponseParseError only tells its timestamp could not be read
+	return result, err
+}
+
+// balance returns the balance of the transaction from the store, or from PayPal the first time
+func (m *RefundManager) balance(transactionID string) (*RefundBalance, error) {
+	b, err := m.Store.Get(transactionID)
+	if err != nil || b != nil {
+		return b, err
+	}
+
+	details, err := m.Client.GetTransactionDetails(transactionID)
+	if requestFailed(err) {
+		return nil, err
+	}
+	b = &RefundBalance{
+		TransactionID:  transactionID,
+		CurrencyCode:   details.CurrencyCode,
+		CapturedAmount: details.Amount,
+		RefundedAmount: formatAmount(0, details.CurrencyCode),
+	}
+	switch details.PaymentStatus {
+	case PaymentStatusRefunded:
+		b.RefundedAmount = b.CapturedAmount
+	case PaymentStatusPartiallyRefunded:
+		if b.RefundedAmount, err = m.searchRefunds(details); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := b.remaining(); err != nil {
+		return nil, err
+	}
+	return b, m.Store.Save(b)
+}
+
+// searchRefunds returns the total of the refunds made of the transaction so far. When the order time of the transaction
+// is unknown, the refunds are searched within the refundSearchWindow rather than from year 1
+func (m *RefundManager) searchRefunds(details *TransactionDetails) (string, error) {
+	start := details.OrderTime
+	if start.IsZero() {
+		start = time.Now().Add(-refundSearchWindow)
+	}
+	results, err := m.Client.TransactionSearch(TransactionSearch{
+		StartDate:     start,
+		TransactionID: details.TransactionID,
+	})
+	if requestFailed(err) {
+		return "", err
+	}
+	total := int64(0)
+	for _, result := range results {
+		if result.TransactionID == details.TransactionID || !strings.EqualFold(result.Type, "Refund") {
+			continue
+		}
+		amount, err := parseAmount(strings.TrimPrefix(result.Amount, "-"), details.CurrencyCode)
+		if err != nil {
+			return "", err
+		}
+		total += amount
+	}
+	return formatAmount(total, details.CurrencyCode), nil
+}
+
+// lock returns the lock serializing the refunds of the transaction
+func (m *RefundManager) lock(transactionID string) *sync.Mutex {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.locks == nil {
+		m.locks = map[string]*sync.Mutex{}
+	}
+	lock, ok := m.locks[transactionID]
+	if !ok {
+		lock = &sync.Mutex{}
+		m.locks[transactionID] = lock
+	}
+	return lock
+}
@@ -0,0 +1,184 @@
+package paypal_test
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/japhy-team/paypal"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefundManager(t *testing.T) {
+	var requests []url.Values
+	client := newTestClient(t, func(r url.Values) url.Values {
+		requests = append(requests, r)
+		switch r.Get("METHOD") {
+		case "GetTransactionDetails":
+			return url.Values{"ACK": {"Success"}, "TRANSACTIONID": {"TX1"}, "AMT": {"50.00"}, "CURRENCYCODE": {"EUR"}, "PAYMENTSTATUS": {"Completed"}}
+		default:
+			return url.Values{"ACK": {"Success"}, "REFUNDTRANSACTIONID": {"REFUND"}, "GROSSREFUNDAMT": {r.Get("AMT")}}
+		}
+	})
+	store := paypal.NewMemoryRefundStore()
+	manager := paypal.NewRefundManager(client, store)
+
+	_, err := manager.Refund(paypal.RefundRequest{TransactionID: "TX1", Type: paypal.RefundPartial, Amount: "30.00"})
+	assert.NoError(t, err)
+	if assert.Len(t, requests, 2) {
+		assert.Equal(t, "GetTransactionDetails", requests[0].Get("METHOD"))
+		assert.Equal(t, "30.00", requests[1].Get("AMT"))
+		assert.Equal(t, "EUR", requests[1].Get("CURRENCYCODE"))
+	}
+
+	_, err = manager.Refund(paypal.RefundRequest{TransactionID: "TX1", Type: paypal.RefundPartial, Amount: "20.01"})
+	if assert.IsType(t, &paypal.RefundExceedsBalanceError{}, err) {
+		assert.Equal(t, "20.00", err.(*paypal.RefundExceedsBalanceError).Remaining)
+	}
+	_, err = manager.Refund(paypal.RefundRequest{TransactionID: "TX1", Type: paypal.RefundPartial, Amount: "5.00", CurrencyCode: "USD"})
+	assert.IsType(t, &paypal.ValidationError{}, err)
+	assert.Len(t, requests, 2)
+
+	// What remains is refunded as a partial refund
+	_, err = manager.Refund(paypal.RefundRequest{TransactionID: "TX1", Type: paypal.RefundFull})
+	assert.NoError(t, err)
+	if assert.Len(t, requests, 3) {
+		assert.Equal(t, "Partial", requests[2].Get("REFUNDTYPE"))
+		assert.Equal(t, "20.00", requests[2].Get("AMT"))
+	}
+
+	balance, err := store.Get("TX1")
+	assert.NoError(t, err)
+	assert.Equal(t, &paypal.RefundBalance{TransactionID: "TX1", CurrencyCode: "EUR", CapturedAmount: "50.00", RefundedAmount: "50.00"}, balance)
+	_, err = manager.Refund(paypal.RefundRequest{TransactionID: "TX1", Type: paypal.RefundPartial, Amount: "0.01"})
+	assert.IsType(t, &paypal.RefundExceedsBalanceError{}, err)
+	assert.Len(t, requests, 3)
+}
+
+func TestRefundManagerSeedsPartialRefunds(t *testing.T) {
+	client := newTestClient(t, func(r url.Values) url.Values {
+		switch r.Get("METHOD") {
+		case "GetTransactionDetails":
+			return url.Values{"ACK": {"Success"}, "TRANSACTIONID": {"TX1"}, "AMT": {"50.00"}, "CURRENCYCODE": {"USD"},
+				"PAYMENTSTATUS": {"Partially-Refunded"}, "ORDERTIME": {"2020-03-01T10:00:00Z"}}
+		case "TransactionSearch":
+			assert.Equal(t, "TX1", r.Get("TRANSACTIONID"))
+			assert.Equal(t, "2020-03-01T10:00:00Z", r.Get("STARTDATE"))
+			return url.Values{
+				"ACK":              {"Success"},
+				"L_TIMESTAMP0":     {"2020-03-05T10:00:00Z"},
+				"L_TRANSACTIONID0": {"REFUND2"},
+				"L_TYPE0":          {"Refund"},
+				"L_AMT0":           {"-5.00"},
+				"L_TIMESTAMP1":     {"2020-03-03T10:00:00Z"},
+				"L_TRANSACTIONID1": {"REFUND1"},
+				"L_TYPE1":          {"Refund"},
+				"L_AMT1":           {"-12.50"},
+				"L_TIMESTAMP2":     {"2020-03-01T10:00:00Z"},
+				"L_TRANSACTIONID2": {"TX1"},
+				"L_TYPE2":          {"Payment"},
+				"L_AMT2":           {"50.00"},
+			}
+		default:
+			return url.Values{"ACK": {"Success"}, "REFUNDTRANSACTIONID": {"REFUND3"}, "TOTALREFUNDEDAMOUNT": {"27.50"}}
+		}
+	})
+	manager := paypal.NewRefundManager(client, paypal.NewMemoryRefundStore())
+
+	balance, err := manager.Balance("TX1")
+	assert.NoError(t, err)
+	assert.Equal(t, "17.50", balance.RefundedAmount)
+	remaining, err := balance.Remaining()
+	assert.NoError(t, err)
+	assert.Equal(t, "32.50", remaining)
+
+	_, err = manager.Refund(paypal.RefundRequest{TransactionID: "TX1", Type: paypal.RefundPartial, Amount: "10.00"})
+	assert.NoError(t, err)
+	balance, err = manager.Balance("TX1")
+	assert.NoError(t, err)
+	assert.Equal(t, "27.50", balance.RefundedAmount)
+}
+
+func TestRefundManagerForgetsRejectedBalance(t *testing.T) {
+	client := newTestClient(t, func(r url.Values) url.Values {
+		if r.Get("METHOD") == "GetTransactionDetails" {
+			return url.Values{"ACK": {"Success"}, "TRANSACTIONID": {"TX1"}, "AMT": {"50.00"}, "CURRENCYCODE": {"USD"}, "PAYMENTSTATUS": {"Completed"}}
+		}
+		return url.Values{"ACK": {"Failure"}, "L_ERRORCODE0": {"10009"}, "L_SHORTMESSAGE0": {"Transaction refused"}}
+	})
+	store := paypal.NewMemoryRefundStore()
+	manager := paypal.NewRefundManager(client, store)
+
+	_, err := manager.Refund(paypal.RefundRequest{TransactionID: "TX1", Type: paypal.RefundPartial, Amount: "10.00"})
+	assert.IsType(t, &paypal.PayPalError{}, err)
+	balance, err := store.Get("TX1")
+	assert.NoError(t, err)
+	assert.Nil(t, balance)
+}
+
+// failingDeleteStore is a RefundStore that cannot delete balances
+type failingDeleteStore struct {
+	*paypal.MemoryRefundStore
+}
+
+func (s failingDeleteStore) Delete(transactionID string) error {
+	return errors.New("store unavailable")
+}
+
+func TestRefundManagerReportsStaleBalance(t *testing.T) {
+	client := newTestClient(t, func(r url.Values) url.Values {
+		if r.Get("METHOD") == "GetTransactionDetails" {
+			return url.Values{"ACK": {"Success"}, "TRANSACTIONID": {"TX1"}, "AMT": {"50.00"}, "CURRENCYCODE": {"USD"}, "PAYMENTSTATUS": {"Completed"}}
+		}
+		return url.Values{"ACK": {"Failure"}, "L_ERRORCODE0": {"10009"}, "L_SHORTMESSAGE0": {"Transaction refused"}}
+	})
+	store := failingDeleteStore{paypal.NewMemoryRefundStore()}
+	manager := paypal.NewRefundManager(client, store)
+
+	_, err := manager.Refund(paypal.RefundRequest{TransactionID: "TX1", Type: paypal.RefundPartial, Amount: "10.00"})
+	if assert.IsType(t, &paypal.StaleBalanceError{}, err) {
+		assert.EqualError(t, err.(*paypal.StaleBalanceError).StoreErr, "store unavailable")
+		var pError *paypal.PayPalError
+		assert.True(t, errors.As(err, &pError))
+		assert.Equal(t, "10009", pError.ErrorCode)
+	}
+}
+
+func TestRefundManagerSearchesRefundsWithinAWindow(t *testing.T) {
+	var start time.Time
+	client := newTestClient(t, func(r url.Values) url.Values {
+		if r.Get("METHOD") == "GetTransactionDetails" {
+			return url.Values{"ACK": {"Success"}, "TRANSACTIONID": {"TX1"}, "AMT": {"50.00"}, "CURRENCYCODE": {"USD"},
+				"PAYMENTSTATUS": {"Partially-Refunded"}}
+		}
+		start, _ = paypal.ParseTime(r.Get("STARTDATE"))
+		return url.Values{"ACK": {"Success"}, "L_TIMESTAMP0": {"2020-03-05T10:00:00Z"}, "L_TRANSACTIONID0": {"REFUND1"},
+			"L_TYPE0": {"Refund"}, "L_AMT0": {"-5.00"}}
+	})
+	manager := paypal.NewRefundManager(client, paypal.NewMemoryRefundStore())
+
+	balance, err := manager.Balance("TX1")
+	assert.NoError(t, err)
+	assert.Equal(t, "5.00", balance.RefundedAmount)
+	// The order time is unknown, the search does not start from year 1
+	assert.WithinDuration(t, time.Now().Add(-180*24*time.Hour), start, time.Minute)
+}
+
+func TestRefundManagerRecordsRefundsWithAnInvalidTimestamp(t *testing.T) {
+	client := newTestClient(t, func(r url.Values) url.Values {
+		if r.Get("METHOD") == "GetTransactionDetails" {
+			return url.Values{"ACK": {"Success"}, "TRANSACTIONID": {"TX1"}, "AMT": {"50.00"}, "CURRENCYCODE": {"USD"}, "PAYMENTSTATUS": {"Completed"}}
+		}
+		return url.Values{"ACK": {"Success"}, "TIMESTAMP": {"not a date"}, "REFUNDTRANSACTIONID": {"REFUND1"}}
+	})
+	manager := paypal.NewRefundManager(client, paypal.NewMemoryRefundStore())
+
+	result, err := manager.Refund(paypal.RefundRequest{TransactionID: "TX1", Type: paypal.RefundPartial, Amount: "10.00"})
+	assert.IsType(t, &paypal.ResponseParseError{}, err)
+	assert.Equal(t, "REFUND1", result.RefundTransactionID)
+	balance, err := manager.Balance("TX1")
+	assert.NoError(t, err)
+	assert.Equal(t, "10.00", balance.RefundedAmount)
+}
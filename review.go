@@ -0,0 +1,215 @@
+package paypal
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// PendingAction is the decision ManagePendingTransactionStatus sends about a pending transaction
+type PendingAction string
+
+// These constants are the decisions that can be made about a pending transaction
+const (
+	PendingAccept PendingAction = "Accept"
+	PendingDeny   PendingAction = "Deny"
+)
+
+// PendingStatusResult is the typed response of ManagePendingTransactionStatus
+type PendingStatusResult struct {
+	TransactionID string        `json:"transactionid,omitempty"`
+	Status        PaymentStatus `json:"status,omitempty"`
+}
+
+// ManagePendingTransactionStatus accepts or denies a transaction held by the Fraud Management Filters
+// See https://developer.paypal.com/docs/classic/api/merchant/ManagePendingTransactionStatus-API-Operation-NVP/ for details
+func (pClient *PayPalClient) ManagePendingTransactionStatus(transactionID string, action PendingAction) (*PendingStatusResult, error) {
+	values := url.Values{}
+	values.Set("METHOD", "ManagePendingTransactionStatus")
+	values.Set("TRANSACTIONID", transactionID)
+	values.Set("ACTION", string(action))
+
+	response, err := pClient.PerformRequest(values)
+	if requestFailed(err) {
+		return nil, err
+	}
+	return &PendingStatusResult{
+		TransactionID: response.Values.Get("TRANSACTIONID"),
+		Status:        ParsePaymentStatus(response.Values.Get("STATUS")),
+	}, err
+}
+
+// ErrReviewTimeout is returned by PaymentReview.Wait when the payment is still pending after the Timeout
+var ErrReviewTimeout = errors.New("paypal: the payment is still pending")
+
+// PaymentReview follows payments that are pending, such as those PayPal holds for review
+// (PendingReasonPaymentReview), until they are completed or denied.
+// Wait polls GetTransactionDetails, first after InitialDelay then twice as long each time up to MaxDelay, and gives up
+// after Timeout. IPNs handed to HandleIPN make the payments they are about checked right away.
+// Once a payment went through OnCompleted is called, even when it was refunded since, and OnDenied once it was denied,
+// failed, or was reversed, voided or expired. Any other status, such as None or one PayPal added since, calls neither and keeps being polled
+type PaymentReview struct {
+	Client       *PayPalClient
+	OnCompleted  func(details *TransactionDetails)
+	OnDenied     func(details *TransactionDetails)
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	Timeout      time.Duration
+
+	mutex   sync.Mutex
+	waiters map[string][]chan struct{}
+}
+
+// These constants are the default delays of a PaymentReview. PayPal reviews payments within 24 hours
+const (
+	DefaultReviewInitialDelay = time.Minute
+	DefaultReviewMaxDelay     = 30 * time.Minute
+	DefaultReviewTimeout      = 48 * time.Hour
+)
+
+// NewPaymentReview returns a PaymentReview calling onCompleted or onDenied once a payment is no longer pending.
+// Either callback may be nil
+func NewPaymentReview(client *PayPalClient, onCompleted, onDenied func(details *TransactionDetails)) *PaymentReview {
+	return &PaymentReview{
+		Client:       client,
+		OnCompleted:  onCompleted,
+		OnDenied:     onDenied,
+		InitialDelay: DefaultReviewInitialDelay,
+		MaxDelay:     DefaultReviewMaxDelay,
+		Timeout:      DefaultReviewTimeout,
+	}
+}
+
+// Wait blocks until the transaction went through or was denied, calls the callback of its outcome and returns its
+// details.
+// Only errors reaching PayPal are retried. Any other error, such as the PayPalError of an unknown transaction or a
+// *ResponseParseError, is returned right away. When the Timeout runs out, the last details are returned along with
+// ErrReviewTimeout
+func (r *PaymentReview) Wait(transactionID string) (*TransactionDetails, error) {
+	wake := r.register(transactionID)
+	defer r.unregister(transactionID, wake)
+
+	var deadline time.Time
+	if r.Timeout > 0 {
+		deadline = time.Now().Add(r.Timeout)
+	}
+	delay := r.InitialDelay
+	if delay <= 0 {
+		delay = DefaultReviewInitialDelay
+	}
+
+	var last *TransactionDetails
+	for {
+		details, err := r.Client.GetTransactionDetails(transactionID)
+		if err != nil && !isTransportError(err) {
+			return details, err
+		}
+		if err == nil {
+			last = details
+			if isReviewResolved(details.PaymentStatus) {
+				r.resolve(details)
+				return details, nil
+			}
+		}
+
+		if !deadline.IsZero() && !time.Now().Add(delay).Before(deadline) {
+			return last, ErrReviewTimeout
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-wake:
+			timer.Stop()
+		}
+		delay *= 2
+		if r.MaxDelay > 0 && delay > r.MaxDelay {
+			delay = r.MaxDelay
+		}
+	}
+}
+
+// HandleIPN makes the payment an IPN is about checked right away when it is being waited for, and reports whether
+// it was. The IPN itself is not trusted: the outcome is always read from GetTransactionDetails
+func (r *PaymentReview) HandleIPN(values url.Values) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	waiters := r.waiters[values.Get("txn_id")]
+	for _, wake := range waiters {
+		select {
+		case wake <- struct{}{}:
+		default:
+			// A check is already due
+		}
+	}
+	return len(waiters) != 0
+}
+
+// isTransportError reports whether err is a failure to reach PayPal or to read its answer, which is worth retrying
+func isTransportError(err error) bool {
+	var urlErr *url.Error
+	var netErr net.Error
+	return errors.As(err, &urlErr) || errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// isReviewResolved reports whether the status tells for sure whether a payment went through or was denied
+func isReviewResolved(status PaymentStatus) bool {
+	return isCompleted(status) || isDenied(status)
+}
+
+// isCompleted reports whether the payment went through, including when it was refunded since
+func isCompleted(status PaymentStatus) bool {
+	return status.IsSuccess() || ParsePaymentStatus(string(status)) == PaymentStatusRefunded
+}
+
+// isDenied reports whether the payment did not go through, and never will
+func isDenied(status PaymentStatus) bool {
+	switch ParsePaymentStatus(string(status)) {
+	case PaymentStatusDenied, PaymentStatusFailed, PaymentStatusReversed, PaymentStatusVoided, PaymentStatusExpired:
+		return true
+	default:
+		return false
+	}
+}
+
+// resolve calls the callback of the outcome of a payment whose review is resolved
+func (r *PaymentReview) resolve(details *TransactionDetails) {
+	if isCompleted(details.PaymentStatus) {
+		if r.OnCompleted != nil {
+			r.OnCompleted(details)
+		}
+	} else if isDenied(details.PaymentStatus) && r.OnDenied != nil {
+		r.OnDenied(details)
+	}
+}
+
+func (r *PaymentReview) register(transactionID string) chan struct{} {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.waiters == nil {
+		r.waiters = map[string][]chan struct{}{}
+	}
+	wake := make(chan struct{}, 1)
+	r.waiters[transactionID] = append(r.waiters[transactionID], wake)
+	return wake
+}
+
+func (r *PaymentReview) unregister(transactionID string, wake chan struct{}) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	waiters := r.waiters[transactionID]
+	for i, w := range waiters {
+		if w == wake {
+			waiters = append(waiters[:i], waiters[i+1:]...)
+			break
+		}
+	}
+	if len(waiters) == 0 {
+		delete(r.waiters, transactionID)
+	} else {
+		r.waiters[transactionID] = waiters
+	}
+}
@@ -0,0 +1,205 @@
+package paypal_test
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/japhy-team/paypal"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newReviewClient returns a client whose GetTransactionDetails reports the status returned by status
+func newReviewClient(t *testing.T, status func(calls int) string) (*paypal.PayPalClient, func() int) {
+	var mutex sync.Mutex
+	calls := 0
+	client := newTestClient(t, func(r url.Values) url.Values {
+		mutex.Lock()
+		defer mutex.Unlock()
+		calls++
+		response := url.Values{"ACK": {"Success"}, "TRANSACTIONID": {r.Get("TRANSACTIONID")}, "PAYMENTSTATUS": {status(calls)}}
+		if status(calls) == "Pending" {
+			response.Set("PENDINGREASON", "paymentreview")
+		}
+		return response
+	})
+	return client, func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return calls
+	}
+}
+
+func TestManagePendingTransactionStatus(t *testing.T) {
+	var request url.Values
+	client := newTestClient(t, func(r url.Values) url.Values {
+		request = r
+		return url.Values{"ACK": {"Success"}, "TRANSACTIONID": {"TX1"}, "STATUS": {"Denied"}}
+	})
+
+	result, err := client.ManagePendingTransactionStatus("TX1", paypal.PendingDeny)
+	assert.NoError(t, err)
+	assert.Equal(t, "ManagePendingTransactionStatus", request.Get("METHOD"))
+	assert.Equal(t, "Deny", request.Get("ACTION"))
+	assert.Equal(t, paypal.PaymentStatusDenied, result.Status)
+}
+
+func TestPaymentReviewPolls(t *testing.T) {
+	client, calls := newReviewClient(t, func(calls int) string {
+		if calls < 3 {
+			return "Pending"
+		}
+		return "Completed"
+	})
+	var completed, denied []string
+	review := paypal.NewPaymentReview(client,
+		func(details *paypal.TransactionDetails) { completed = append(completed, details.TransactionID) },
+		func(details *paypal.TransactionDetails) { denied = append(denied, details.TransactionID) })
+	review.InitialDelay = time.Millisecond
+
+	details, err := review.Wait("TX1")
+	assert.NoError(t, err)
+	assert.Equal(t, paypal.PaymentStatusCompleted, details.PaymentStatus)
+	assert.Equal(t, 3, calls())
+	assert.Equal(t, []string{"TX1"}, completed)
+	assert.Empty(t, denied)
+}
+
+func TestPaymentReviewDenied(t *testing.T) {
+	client, _ := newReviewClient(t, func(calls int) string { return "Denied" })
+	var denied *paypal.TransactionDetails
+	review := paypal.NewPaymentReview(client, nil, func(details *paypal.TransactionDetails) { denied = details })
+
+	_, err := review.Wait("TX1")
+	assert.NoError(t, err)
+	if assert.NotNil(t, denied) {
+		assert.Equal(t, paypal.PaymentStatusDenied, denied.PaymentStatus)
+	}
+}
+
+func TestPaymentReviewKeepsPollingUnknownStatuses(t *testing.T) {
+	client, calls := newReviewClient(t, func(calls int) string {
+		switch calls {
+		case 1:
+			return "None"
+		case 2:
+			return "Under-Investigation"
+		default:
+			return "Voided"
+		}
+	})
+	var denied []string
+	review := paypal.NewPaymentReview(client,
+		func(details *paypal.TransactionDetails) {
+			t.Error("An unknown status was taken for a completed payment")
+		},
+		func(details *paypal.TransactionDetails) { denied = append(denied, string(details.PaymentStatus)) })
+	review.InitialDelay = time.Millisecond
+
+	_, err := review.Wait("TX1")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls())
+	assert.Equal(t, []string{"Voided"}, denied)
+}
+
+func TestPaymentReviewUnknownStatusTimesOut(t *testing.T) {
+	client, _ := newReviewClient(t, func(calls int) string { return "" })
+	review := paypal.NewPaymentReview(client, nil,
+		func(details *paypal.TransactionDetails) { t.Error("An empty status was taken for a denied payment") })
+	review.InitialDelay = time.Millisecond
+	review.Timeout = 20 * time.Millisecond
+
+	_, err := review.Wait("TX1")
+	assert.Equal(t, paypal.ErrReviewTimeout, err)
+}
+
+func TestPaymentReviewIPN(t *testing.T) {
+	var mutex sync.Mutex
+	status := "Pending"
+	client, calls := newReviewClient(t, func(int) string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return status
+	})
+	review := paypal.NewPaymentReview(client, nil, nil)
+	review.InitialDelay = time.Hour
+
+	done := make(chan *paypal.TransactionDetails)
+	go func() {
+		details, err := review.Wait("TX1")
+		assert.NoError(t, err)
+		done <- details
+	}()
+
+	assert.False(t, review.HandleIPN(url.Values{"txn_id": {"TX2"}, "payment_status": {"Completed"}}))
+	for calls() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	mutex.Lock()
+	status = "Completed"
+	mutex.Unlock()
+	assert.True(t, review.HandleIPN(url.Values{"txn_id": {"TX1"}, "payment_status": {"Completed"}}))
+
+	select {
+	case details := <-done:
+		assert.Equal(t, paypal.PaymentStatusCompleted, details.PaymentStatus)
+	case <-time.After(5 * time.Second):
+		t.Fatal("The IPN did not wake the review up")
+	}
+}
+
+func TestPaymentReviewTimeout(t *testing.T) {
+	client, _ := newReviewClient(t, func(int) string { return "Pending" })
+	review := paypal.NewPaymentReview(client, nil, nil)
+	review.InitialDelay = time.Millisecond
+	review.Timeout = 20 * time.Millisecond
+
+	details, err := review.Wait("TX1")
+	assert.Equal(t, paypal.ErrReviewTimeout, err)
+	assert.Equal(t, paypal.PendingReasonPaymentReview, details.PendingReason)
+}
+
+func TestPaymentReviewRefunded(t *testing.T) {
+	client, calls := newReviewClient(t, func(calls int) string { return "Refunded" })
+	var completed []string
+	review := paypal.NewPaymentReview(client,
+		func(details *paypal.TransactionDetails) { completed = append(completed, string(details.PaymentStatus)) },
+		func(details *paypal.TransactionDetails) { t.Error("A refunded payment was taken for a denied one") })
+	review.Timeout = 20 * time.Millisecond
+
+	_, err := review.Wait("TX1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, calls())
+	assert.Equal(t, []string{"Refunded"}, completed)
+}
+
+func TestPaymentReviewRetriesOnlyTransportErrors(t *testing.T) {
+	calls := 0
+	client := newTestClient(t, func(r url.Values) url.Values {
+		calls++
+		if calls == 1 {
+			panic(http.ErrAbortHandler)
+		}
+		return url.Values{"ACK": {"Success"}, "TIMESTAMP": {"not a date"}, "TRANSACTIONID": {"TX1"}, "PAYMENTSTATUS": {"Pending"}}
+	})
+	review := paypal.NewPaymentReview(client, nil, nil)
+	review.InitialDelay = time.Millisecond
+	review.Timeout = time.Second
+
+	_, err := review.Wait("TX1")
+	assert.IsType(t, &paypal.ResponseParseError{}, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestPaymentReviewUnknownTransaction(t *testing.T) {
+	client := newTestClient(t, func(r url.Values) url.Values {
+		return url.Values{"ACK": {"Failure"}, "L_ERRORCODE0": {"10004"}}
+	})
+	review := paypal.NewPaymentReview(client, nil, nil)
+
+	_, err := review.Wait("TX1")
+	assert.IsType(t, &paypal.PayPalError{}, err)
+}
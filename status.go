@@ -0,0 +1,245 @@
+package paypal
+
+import "strings"
+
+// Ack is the acknowledgement status of a response
+type Ack string
+
+// These constants are the acknowledgement statuses PayPal returns
+const (
+	AckSuccess            Ack = "Success"
+	AckSuccessWithWarning Ack = "SuccessWithWarning"
+	AckFailure            Ack = "Failure"
+	AckFailureWithWarning Ack = "FailureWithWarning"
+)
+
+// PaymentStatus is the status of a payment
+type PaymentStatus string
+
+// These constants are the payment statuses documented by PayPal
+const (
+	PaymentStatusNone               PaymentStatus = "None"
+	PaymentStatusCanceledReversal   PaymentStatus = "Canceled-Reversal"
+	PaymentStatusCompleted          PaymentStatus = "Completed"
+	PaymentStatusCompletedFundsHeld PaymentStatus = "Completed-Funds-Held"
+	PaymentStatusDenied             PaymentStatus = "Denied"
+	PaymentStatusExpired            PaymentStatus = "Expired"
+	PaymentStatusFailed             PaymentStatus = "Failed"
+	PaymentStatusInProgress         PaymentStatus = "In-Progress"
+	PaymentStatusPartiallyRefunded  PaymentStatus = "Partially-Refunded"
+	PaymentStatusPending            PaymentStatus = "Pending"
+	PaymentStatusProcessed          PaymentStatus = "Processed"
+	PaymentStatusRefunded           PaymentStatus = "Refunded"
+	PaymentStatusReversed           PaymentStatus = "Reversed"
+	PaymentStatusVoided             PaymentStatus = "Voided"
+)
+
+// PendingReason is the reason a payment is pending
+type PendingReason string
+
+// These constants are the pending reasons documented by PayPal
+const (
+	PendingReasonNone                PendingReason = "none"
+	PendingReasonAddress             PendingReason = "address"
+	PendingReasonAuthorization       PendingReason = "authorization"
+	PendingReasonDelayedDisbursement PendingReason = "delayeddisbursement"
+	PendingReasonEcheck              PendingReason = "echeck"
+	PendingReasonIntl                PendingReason = "intl"
+	PendingReasonMultiCurrency       PendingReason = "multi-currency"
+	PendingReasonOrder               PendingReason = "order"
+	PendingReasonPaymentReview       PendingReason = "paymentreview"
+	PendingReasonRegulatoryReview    PendingReason = "regulatoryreview"
+	PendingReasonUnilateral          PendingReason = "unilateral"
+	PendingReasonVerify              PendingReason = "verify"
+	PendingReasonOther               PendingReason = "other"
+)
+
+// ReasonCode is the reason a payment was reversed
+type ReasonCode string
+
+// These constants are the reason codes documented by PayPal
+const (
+	ReasonCodeNone                    ReasonCode = "none"
+	ReasonCodeChargeback              ReasonCode = "chargeback"
+	ReasonCodeGuarantee               ReasonCode = "guarantee"
+	ReasonCodeBuyerComplaint          ReasonCode = "buyer-complaint"
+	ReasonCodeRefund                  ReasonCode = "refund"
+	ReasonCodeAdjustmentReversal      ReasonCode = "adjustment_reversal"
+	ReasonCodeChargebackReimbursement ReasonCode = "chargeback_reimbursement"
+	ReasonCodeChargebackSettlement    ReasonCode = "chargeback_settlement"
+	ReasonCodeOther                   ReasonCode = "other"
+)
+
+// PaymentType tells whether a payment is funded instantly or by an eCheck that has to clear
+type PaymentType string
+
+// These constants are the payment types documented by PayPal
+const (
+	PaymentTypeNone    PaymentType = "none"
+	PaymentTypeEcheck  PaymentType = "echeck"
+	PaymentTypeInstant PaymentType = "instant"
+)
+
+var (
+	acks = knownValues(string(AckSuccess), string(AckSuccessWithWarning), string(AckFailure),
+		string(AckFailureWithWarning))
+	paymentStatuses = knownValues(string(PaymentStatusNone), string(PaymentStatusCanceledReversal),
+		string(PaymentStatusCompleted), string(PaymentStatusCompletedFundsHeld), string(PaymentStatusDenied),
+		string(PaymentStatusExpired), string(PaymentStatusFailed), string(PaymentStatusInProgress),
+		string(PaymentStatusPartiallyRefunded), string(PaymentStatusPending), string(PaymentStatusProcessed),
+		string(PaymentStatusRefunded), string(PaymentStatusReversed), string(PaymentStatusVoided))
+	pendingReasons = knownValues(string(PendingReasonNone), string(PendingReasonAddress),
+		string(PendingReasonAuthorization), string(PendingReasonDelayedDisbursement), string(PendingReasonEcheck),
+		string(PendingReasonIntl), string(PendingReasonMultiCurrency), string(PendingReasonOrder),
+		string(PendingReasonPaymentReview), string(PendingReasonRegulatoryReview), string(PendingReasonUnilateral),
+		string(PendingReasonVerify), string(PendingReasonOther))
+	reasonCodes = knownValues(string(ReasonCodeNone), string(ReasonCodeChargeback), string(ReasonCodeGuarantee),
+		string(ReasonCodeBuyerComplaint), string(ReasonCodeRefund), string(ReasonCodeAdjustmentReversal),
+		string(ReasonCodeChargebackReimbursement), string(ReasonCodeChargebackSettlement), string(ReasonCodeOther))
+	paymentTypes = knownValues(string(PaymentTypeNone), string(PaymentTypeEcheck), string(PaymentTypeInstant))
+)
+
+// knownValues indexes the documented values of an enum by their lower case form, PayPal not being consistent
+// about the case of the values it sends
+func knownValues(values ...string) map[string]string {
+	known := make(map[string]string, len(values))
+	for _, value := range values {
+		known[strings.ToLower(value)] = value
+	}
+	return known
+}
+
+// canonical returns the documented value equal to raw regardless of case, or raw itself when there is none
+func canonical(raw string, known map[string]string) string {
+	if value, ok := known[strings.ToLower(raw)]; ok {
+		return value
+	}
+	return raw
+}
+
+// isKnown reports whether raw is a documented value regardless of case
+func isKnown(raw string, known map[string]string) bool {
+	_, ok := known[strings.ToLower(raw)]
+	return ok
+}
+
+// ParseAck returns the Ack of raw regardless of case. Values that are not documented are kept as they are
+func ParseAck(raw string) Ack {
+	return Ack(canonical(raw, acks))
+}
+
+// IsUnknown reports whether the Ack is not one of the documented values
+func (a Ack) IsUnknown() bool {
+	return !isKnown(string(a), acks)
+}
+
+// IsSuccess reports whether the request succeeded, with or without warnings
+func (a Ack) IsSuccess() bool {
+	a = ParseAck(string(a))
+	return a == AckSuccess || a == AckSuccessWithWarning
+}
+
+// IsFailure reports whether the request failed, with or without warnings
+func (a Ack) IsFailure() bool {
+	a = ParseAck(string(a))
+	return a == AckFailure || a == AckFailureWithWarning
+}
+
+// HasWarning reports whether the response carries warnings
+func (a Ack) HasWarning() bool {
+	a = ParseAck(string(a))
+	return a == AckSuccessWithWarning || a == AckFailureWithWarning
+}
+
+// ParsePaymentStatus returns the PaymentStatus of raw regardless of case. Values that are not documented are kept as
+// they are
+func ParsePaymentStatus(raw string) PaymentStatus {
+	return PaymentStatus(canonical(raw, paymentStatuses))
+}
+
+// IsUnknown reports whether the status is not one of the documented values
+func (s PaymentStatus) IsUnknown() bool {
+	return !isKnown(string(s), paymentStatuses)
+}
+
+// IsPending reports whether PayPal has yet to complete or deny the payment
+func (s PaymentStatus) IsPending() bool {
+	s = ParsePaymentStatus(string(s))
+	return s == PaymentStatusPending || s == PaymentStatusInProgress
+}
+
+// IsSuccess reports whether the payment went through, even if it was partially refunded since
+func (s PaymentStatus) IsSuccess() bool {
+	switch ParsePaymentStatus(string(s)) {
+	case PaymentStatusCompleted, PaymentStatusCompletedFundsHeld, PaymentStatusProcessed,
+		PaymentStatusPartiallyRefunded, PaymentStatusCanceledReversal:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsFinal reports whether the payment reached a status PayPal will not move it out of on its own.
+// A completed payment is final even though a refund or a reversal may follow; pending, none and unknown statuses are not
+func (s PaymentStatus) IsFinal() bool {
+	switch ParsePaymentStatus(string(s)) {
+	case PaymentStatusCanceledReversal, PaymentStatusCompleted, PaymentStatusCompletedFundsHeld,
+		PaymentStatusDenied, PaymentStatusExpired, PaymentStatusFailed, PaymentStatusPartiallyRefunded,
+		PaymentStatusProcessed, PaymentStatusRefunded, PaymentStatusReversed, PaymentStatusVoided:
+		return true
+	default:
+		return false
+	}
+}
+
+// ParsePendingReason returns the PendingReason of raw regardless of case. Values that are not documented are kept as
+// they are
+func ParsePendingReason(raw string) PendingReason {
+	return PendingReason(canonical(raw, pendingReasons))
+}
+
+// IsUnknown reports whether the reason is not one of the documented values
+func (r PendingReason) IsUnknown() bool {
+	return !isKnown(string(r), pendingReasons)
+}
+
+// IsReview reports whether the payment is pending until PayPal or a regulator reviewed it
+func (r PendingReason) IsReview() bool {
+	r = ParsePendingReason(string(r))
+	return r == PendingReasonPaymentReview || r == PendingReasonRegulatoryReview
+}
+
+// ParseReasonCode returns the ReasonCode of raw regardless of case. Values that are not documented are kept as they are
+func ParseReasonCode(raw string) ReasonCode {
+	return ReasonCode(canonical(raw, reasonCodes))
+}
+
+// IsUnknown reports whether the reason code is not one of the documented values
+func (c ReasonCode) IsUnknown() bool {
+	return !isKnown(string(c), reasonCodes)
+}
+
+// IsChargeback reports whether the payment was reversed by a chargeback, or funds were returned after one
+func (c ReasonCode) IsChargeback() bool {
+	switch ParseReasonCode(string(c)) {
+	case ReasonCodeChargeback, ReasonCodeChargebackReimbursement, ReasonCodeChargebackSettlement:
+		return true
+	default:
+		return false
+	}
+}
+
+// ParsePaymentType returns the PaymentType of raw regardless of case. Values that are not documented are kept as they are
+func ParsePaymentType(raw string) PaymentType {
+	return PaymentType(canonical(raw, paymentTypes))
+}
+
+// IsUnknown reports whether the payment type is not one of the documented values
+func (t PaymentType) IsUnknown() bool {
+	return !isKnown(string(t), paymentTypes)
+}
+
+// IsInstant reports whether the payment is funded instantly
+func (t PaymentType) IsInstant() bool {
+	return ParsePaymentType(string(t)) == PaymentTypeInstant
+}
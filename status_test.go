@@ -0,0 +1,58 @@
+package paypal_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/japhy-team/paypal"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStatuses(t *testing.T) {
+	assert.Equal(t, paypal.AckSuccessWithWarning, paypal.ParseAck("successwithwarning"))
+	assert.Equal(t, paypal.PaymentStatusPartiallyRefunded, paypal.ParsePaymentStatus("Partially-Refunded"))
+	assert.Equal(t, paypal.PendingReasonMultiCurrency, paypal.ParsePendingReason("Multi-Currency"))
+	assert.Equal(t, paypal.ReasonCodeBuyerComplaint, paypal.ParseReasonCode("buyer-complaint"))
+	assert.Equal(t, paypal.PaymentTypeEcheck, paypal.ParsePaymentType("eCheck"))
+
+	// Values PayPal did not document are kept as they are
+	status := paypal.ParsePaymentStatus("Completed-Later")
+	assert.Equal(t, paypal.PaymentStatus("Completed-Later"), status)
+	assert.True(t, status.IsUnknown())
+	assert.False(t, status.IsFinal())
+	assert.False(t, status.IsSuccess())
+	assert.False(t, paypal.PaymentStatusCompleted.IsUnknown())
+}
+
+func TestStatusPredicates(t *testing.T) {
+	assert.True(t, paypal.AckSuccessWithWarning.IsSuccess())
+	assert.True(t, paypal.AckSuccessWithWarning.HasWarning())
+	assert.True(t, paypal.Ack("failure").IsFailure())
+	assert.False(t, paypal.AckFailure.IsSuccess())
+
+	assert.True(t, paypal.PaymentStatusCompleted.IsFinal())
+	assert.True(t, paypal.PaymentStatusCompleted.IsSuccess())
+	assert.True(t, paypal.PaymentStatusDenied.IsFinal())
+	assert.False(t, paypal.PaymentStatusDenied.IsSuccess())
+	assert.True(t, paypal.PaymentStatusPending.IsPending())
+	assert.False(t, paypal.PaymentStatusPending.IsFinal())
+	assert.False(t, paypal.PaymentStatusInProgress.IsFinal())
+
+	assert.True(t, paypal.PendingReasonPaymentReview.IsReview())
+	assert.False(t, paypal.PendingReasonEcheck.IsReview())
+	assert.True(t, paypal.ReasonCodeChargebackSettlement.IsChargeback())
+	assert.True(t, paypal.PaymentTypeInstant.IsInstant())
+}
+
+func TestPerformRequestFailureAck(t *testing.T) {
+	client := newTestClient(t, func(r url.Values) url.Values {
+		return url.Values{"ACK": {"FAILUREWITHWARNING"}}
+	})
+
+	response, err := client.PerformRequest(url.Values{"METHOD": {"GetBalance"}})
+	assert.Equal(t, paypal.AckFailureWithWarning, response.Ack)
+	if assert.IsType(t, &paypal.PayPalError{}, err) {
+		assert.Equal(t, "FailureWithWarning", err.Error())
+	}
+}
@@ -0,0 +1,38 @@
+package paypal
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// TimeLayout is the layout of the dates PayPal expects in requests, always in UTC
+const TimeLayout = "2006-01-02T15:04:05Z"
+
+// ParseTime parses a date sent by PayPal and returns it in UTC. PayPal sends its dates in UTC in the form of
+// TimeLayout, but any RFC 3339 date is accepted
+func ParseTime(value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.UTC(), nil
+}
+
+// FormatTime formats t for a date parameter of a request, in UTC in the form of TimeLayout
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(TimeLayout)
+}
+
+// parseTimeValue parses the date of the response values under key. A missing or empty date is the zero time
+func parseTimeValue(values url.Values, key string) (time.Time, error) {
+	value := values.Get(key)
+	if len(value) == 0 {
+		return time.Time{}, nil
+	}
+	t, err := ParseTime(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("paypal: %s: %v", key, err)
+	}
+	return t, nil
+}
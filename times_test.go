@@ -0,0 +1,117 @@
+package paypal_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/japhy-team/paypal"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAndFormatTime(t *testing.T) {
+	parsed, err := paypal.ParseTime("2020-03-01T10:30:00Z")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2020, 3, 1, 10, 30, 0, 0, time.UTC), parsed)
+
+	parsed, err = paypal.ParseTime("2020-03-01T11:30:00+01:00")
+	assert.NoError(t, err)
+	assert.Equal(t, time.UTC, parsed.Location())
+	assert.Equal(t, "2020-03-01T10:30:00Z", paypal.FormatTime(parsed))
+
+	_, err = paypal.ParseTime("03/01/2020")
+	assert.Error(t, err)
+
+	paris, err := time.LoadLocation("Europe/Paris")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "2020-03-01T10:30:00Z", paypal.FormatTime(time.Date(2020, 3, 1, 11, 30, 0, 0, paris)))
+	}
+}
+
+func TestResponseTimes(t *testing.T) {
+	var request url.Values
+	client := newTestClient(t, func(r url.Values) url.Values {
+		request = r
+		return url.Values{"ACK": {"Success"}, "TIMESTAMP": {"2020-03-01T10:30:00Z"}, "ORDERTIME": {"2020-03-01T10:29:58Z"}}
+	})
+
+	response, err := client.ProfileTransactionSearch("I-PROFILE", time.Date(2020, 2, 1, 1, 0, 0, 0, time.FixedZone("CET", 3600)))
+	assert.NoError(t, err)
+	assert.Equal(t, "2020-02-01T00:00:00Z", request.Get("STARTDATE"))
+	assert.Equal(t, time.Date(2020, 3, 1, 10, 30, 0, 0, time.UTC), response.Timestamp)
+
+	values := client.ConvertResponse(*response)
+	assert.Equal(t, time.Date(2020, 3, 1, 10, 29, 58, 0, time.UTC), values.DateOrdered)
+	values, err = client.ParseResponse(*response)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2020, 3, 1, 10, 29, 58, 0, time.UTC), values.DateOrdered)
+
+	response.Values.Set("ORDERTIME", "yesterday")
+	assert.True(t, client.ConvertResponse(*response).DateOrdered.IsZero())
+	values, err = client.ParseResponse(*response)
+	if assert.IsType(t, &paypal.ResponseParseError{}, err) {
+		assert.Contains(t, err.Error(), "paypal: invalid response: ORDERTIME: ")
+	}
+	assert.True(t, values.DateOrdered.IsZero())
+	assert.Equal(t, "Success", string(values.Ack))
+}
+
+func TestResponseInvalidTimestamp(t *testing.T) {
+	client := newTestClient(t, func(r url.Values) url.Values {
+		return url.Values{"ACK": {"Success"}, "TIMESTAMP": {"not a date"}}
+	})
+
+	response, err := client.GetRecurringPaymentsProfileDetails("I-PROFILE")
+	if assert.IsType(t, &paypal.ResponseParseError{}, err) {
+		assert.Len(t, err.(*paypal.ResponseParseError).Errors, 1)
+		assert.Contains(t, err.Error(), "paypal: invalid response: TIMESTAMP: ")
+	}
+	if assert.NotNil(t, response) {
+		assert.True(t, response.Ack.IsSuccess())
+		assert.True(t, response.Timestamp.IsZero())
+	}
+}
+
+func TestRecurringPaymentsProfile(t *testing.T) {
+	client := newTestClient(t, func(r url.Values) url.Values {
+		return url.Values{
+			"ACK":                {"Success"},
+			"PROFILEID":          {"I-PROFILE"},
+			"STATUS":             {"Active"},
+			"PROFILESTARTDATE":   {"2020-01-15T08:00:00Z"},
+			"NEXTBILLINGDATE":    {"2020-04-15T10:00:00Z"},
+			"LASTPAYMENTDATE":    {"2020-03-15T10:12:05Z"},
+			"LASTPAYMENTAMT":     {"9.99"},
+			"NUMCYCLESCOMPLETED": {"3"},
+			"FAILEDPAYMENTCOUNT": {"0"},
+		}
+	})
+
+	response, err := client.GetRecurringPaymentsProfileDetails("I-PROFILE")
+	assert.NoError(t, err)
+	profile, err := response.RecurringPaymentsProfile()
+	assert.NoError(t, err)
+	assert.Equal(t, "Active", profile.Status)
+	assert.Equal(t, time.Date(2020, 1, 15, 8, 0, 0, 0, time.UTC), profile.ProfileStartDate)
+	assert.Equal(t, time.Date(2020, 4, 15, 10, 0, 0, 0, time.UTC), profile.NextBillingDate)
+	assert.True(t, profile.FinalPaymentDueDate.IsZero())
+	assert.Equal(t, 3, profile.NumCyclesCompleted)
+
+	response.Values.Set("NEXTBILLINGDATE", "2020-04-15")
+	_, err = response.RecurringPaymentsProfile()
+	assert.Error(t, err)
+}
+
+func TestInvalidTimestampKeepsTheCaptureResponse(t *testing.T) {
+	client := newTestClient(t, func(r url.Values) url.Values {
+		return url.Values{"ACK": {"Success"}, "TIMESTAMP": {"01/03/2020"}, "TRANSACTIONID": {"TX1"}}
+	})
+
+	response, err := client.DoCapture("100.00", "AUTH1", true, "")
+	// The capture went through, only its timestamp is missing
+	assert.IsType(t, &paypal.ResponseParseError{}, err)
+	if assert.NotNil(t, response) {
+		assert.Equal(t, "TX1", response.Values.Get("TRANSACTIONID"))
+	}
+}
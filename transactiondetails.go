@@ -0,0 +1,151 @@
+package paypal
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ReceiverInfo identifies the account that received a payment
+type ReceiverInfo struct {
+	Business   string `json:"receiverbusiness,omitempty"`
+	Email      string `json:"receiveremail,omitempty"`
+	ReceiverID string `json:"receiverid,omitempty"`
+}
+
+// PayerInfo identifies the account that sent a payment. PayerStatus is verified or unverified
+type PayerInfo struct {
+	Email       string `json:"email,omitempty"`
+	PayerID     string `json:"payerid,omitempty"`
+	PayerStatus string `json:"payerstatus,omitempty"`
+	CountryCode string `json:"countrycode,omitempty"`
+	Business    string `json:"business,omitempty"`
+	Salutation  string `json:"salutation,omitempty"`
+	FirstName   string `json:"firstname,omitempty"`
+	MiddleName  string `json:"middlename,omitempty"`
+	LastName    string `json:"lastname,omitempty"`
+	Suffix      string `json:"suffix,omitempty"`
+}
+
+// TransactionItem is a line item of a transaction
+type TransactionItem struct {
+	Name     string `json:"name,omitempty"`
+	Number   string `json:"number,omitempty"`
+	Quantity int    `json:"qty,omitempty"`
+	Amount   string `json:"amt,omitempty"`
+}
+
+// TransactionDetails is the state of a transaction as reported by GetTransactionDetails.
+// ParentTransactionID is set on refunds, reversals and captures, and is the ID of the transaction they relate to.
+// FeeAmount is the PayPal fee, SettleAmount the amount deposited in the account after a currency conversion at
+// ExchangeRate
+type TransactionDetails struct {
+	Receiver                  ReceiverInfo      `json:"receiver"`
+	Payer                     PayerInfo         `json:"payer"`
+	TransactionID             string            `json:"transactionid,omitempty"`
+	ParentTransactionID       string            `json:"parenttransactionid,omitempty"`
+	ReceiptID                 string            `json:"receiptid,omitempty"`
+	TransactionType           string            `json:"transactiontype,omitempty"`
+	PaymentType               PaymentType       `json:"paymenttype,omitempty"`
+	OrderTime                 time.Time         `json:"ordertime"`
+	Amount                    string            `json:"amt,omitempty"`
+	CurrencyCode              string            `json:"currencycode,omitempty"`
+	FeeAmount                 string            `json:"feeamt,omitempty"`
+	SettleAmount              string            `json:"settleamt,omitempty"`
+	TaxAmount                 string            `json:"taxamt,omitempty"`
+	ExchangeRate              string            `json:"exchangerate,omitempty"`
+	PaymentStatus             PaymentStatus     `json:"paymentstatus,omitempty"`
+	PendingReason             PendingReason     `json:"pendingreason,omitempty"`
+	ReasonCode                ReasonCode        `json:"reasoncode,omitempty"`
+	ProtectionEligibility     string            `json:"protectioneligibility,omitempty"`
+	ProtectionEligibilityType string            `json:"protectioneligibilitytype,omitempty"`
+	InvoiceID                 string            `json:"invnum,omitempty"`
+	Custom                    string            `json:"custom,omitempty"`
+	Note                      string            `json:"note,omitempty"`
+	Items                     []TransactionItem `json:"items,omitempty"`
+}
+
+// GetTransactionDetails returns the current state of a transaction, such as a payment still pending after
+// DoExpressCheckoutPayment or the one an IPN was sent about
+// See https://developer.paypal.com/docs/classic/api/merchant/GetTransactionDetails-API-Operation-NVP/ for details
+func (pClient *PayPalClient) GetTransactionDetails(transactionID string) (*TransactionDetails, error) {
+	values := url.Values{}
+	values.Set("METHOD", "GetTransactionDetails")
+	values.Set("TRANSACTIONID", transactionID)
+
+	response, err := pClient.PerformRequest(values)
+	if requestFailed(err) {
+		return nil, err
+	}
+	details, parseErr := parseTransactionDetails(response.Values)
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	return details, err
+}
+
+// parseTransactionDetails reads the response of GetTransactionDetails
+func parseTransactionDetails(values url.Values) (*TransactionDetails, error) {
+	details := &TransactionDetails{
+		Receiver: ReceiverInfo{
+			Business:   values.Get("RECEIVERBUSINESS"),
+			Email:      values.Get("RECEIVEREMAIL"),
+			ReceiverID: values.Get("RECEIVERID"),
+		},
+		Payer: PayerInfo{
+			Email:       values.Get("EMAIL"),
+			PayerID:     values.Get("PAYERID"),
+			PayerStatus: values.Get("PAYERSTATUS"),
+			CountryCode: values.Get("COUNTRYCODE"),
+			Business:    values.Get("BUSINESS"),
+			Salutation:  values.Get("SALUTATION"),
+			FirstName:   values.Get("FIRSTNAME"),
+			MiddleName:  values.Get("MIDDLENAME"),
+			LastName:    values.Get("LASTNAME"),
+			Suffix:      values.Get("SUFFIX"),
+		},
+		TransactionID:             values.Get("TRANSACTIONID"),
+		ParentTransactionID:       values.Get("PARENTTRANSACTIONID"),
+		ReceiptID:                 values.Get("RECEIPTID"),
+		TransactionType:           values.Get("TRANSACTIONTYPE"),
+		PaymentType:               ParsePaymentType(values.Get("PAYMENTTYPE")),
+		Amount:                    values.Get("AMT"),
+		CurrencyCode:              values.Get("CURRENCYCODE"),
+		FeeAmount:                 values.Get("FEEAMT"),
+		SettleAmount:              values.Get("SETTLEAMT"),
+		TaxAmount:                 values.Get("TAXAMT"),
+		ExchangeRate:              values.Get("EXCHANGERATE"),
+		PaymentStatus:             ParsePaymentStatus(values.Get("PAYMENTSTATUS")),
+		PendingReason:             ParsePendingReason(values.Get("PENDINGREASON")),
+		ReasonCode:                ParseReasonCode(values.Get("REASONCODE")),
+		ProtectionEligibility:     values.Get("PROTECTIONELIGIBILITY"),
+		ProtectionEligibilityType: values.Get("PROTECTIONELIGIBILITYTYPE"),
+		InvoiceID:                 values.Get("INVNUM"),
+		Custom:                    values.Get("CUSTOM"),
+		Note:                      values.Get("NOTE"),
+	}
+
+	var err error
+	if details.OrderTime, err = parseTimeValue(values, "ORDERTIME"); err != nil {
+		return nil, err
+	}
+
+	for i := 0; ; i++ {
+		n := strconv.Itoa(i)
+		if _, ok := values["L_NAME"+n]; !ok {
+			if _, ok := values["L_AMT"+n]; !ok {
+				break
+			}
+		}
+		item := TransactionItem{
+			Name:   values.Get("L_NAME" + n),
+			Number: values.Get("L_NUMBER" + n),
+			Amount: values.Get("L_AMT" + n),
+		}
+		if item.Quantity, err = parseIntValue(values, "L_QTY"+n); err != nil {
+			return nil, err
+		}
+		details.Items = append(details.Items, item)
+	}
+	return details, nil
+}
@@ -0,0 +1,93 @@
+package paypal_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/japhy-team/paypal"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTransactionDetails(t *testing.T) {
+	var request url.Values
+	client := newTestClient(t, func(r url.Values) url.Values {
+		request = r
+		return url.Values{
+			"ACK":                   {"Success"},
+			"RECEIVEREMAIL":         {"merchant@example.com"},
+			"RECEIVERID":            {"MERCHANT123"},
+			"EMAIL":                 {"buyer@example.com"},
+			"PAYERID":               {"PAYER123"},
+			"PAYERSTATUS":           {"verified"},
+			"FIRSTNAME":             {"Test"},
+			"LASTNAME":              {"Buyer"},
+			"TRANSACTIONID":         {"8AB12345CD678901E"},
+			"PARENTTRANSACTIONID":   {"1AB12345CD678901E"},
+			"TRANSACTIONTYPE":       {"expresscheckout"},
+			"PAYMENTTYPE":           {"instant"},
+			"ORDERTIME":             {"2020-03-01T10:30:00Z"},
+			"AMT":                   {"25.00"},
+			"CURRENCYCODE":          {"EUR"},
+			"FEEAMT":                {"1.08"},
+			"SETTLEAMT":             {"26.42"},
+			"EXCHANGERATE":          {"1.10009"},
+			"PAYMENTSTATUS":         {"Pending"},
+			"PENDINGREASON":         {"paymentreview"},
+			"REASONCODE":            {"None"},
+			"PROTECTIONELIGIBILITY": {"Eligible"},
+			"L_NAME0":               {"Book"},
+			"L_QTY0":                {"2"},
+			"L_AMT0":                {"10.00"},
+			"L_NAME1":               {"Bookmark"},
+			"L_QTY1":                {"1"},
+			"L_AMT1":                {"5.00"},
+		}
+	})
+
+	details, err := client.GetTransactionDetails("8AB12345CD678901E")
+	assert.NoError(t, err)
+	assert.Equal(t, "GetTransactionDetails", request.Get("METHOD"))
+	assert.Equal(t, "8AB12345CD678901E", request.Get("TRANSACTIONID"))
+
+	assert.Equal(t, "merchant@example.com", details.Receiver.Email)
+	assert.Equal(t, "PAYER123", details.Payer.PayerID)
+	assert.Equal(t, "Buyer", details.Payer.LastName)
+	assert.Equal(t, "1AB12345CD678901E", details.ParentTransactionID)
+	assert.Equal(t, time.Date(2020, 3, 1, 10, 30, 0, 0, time.UTC), details.OrderTime)
+	assert.Equal(t, "1.08", details.FeeAmount)
+	assert.Equal(t, "26.42", details.SettleAmount)
+	assert.Equal(t, "1.10009", details.ExchangeRate)
+	assert.Equal(t, paypal.PaymentStatusPending, details.PaymentStatus)
+	assert.Equal(t, paypal.PendingReasonPaymentReview, details.PendingReason)
+	assert.Equal(t, "Eligible", details.ProtectionEligibility)
+	assert.Equal(t, []paypal.TransactionItem{
+		{Name: "Book", Quantity: 2, Amount: "10.00"},
+		{Name: "Bookmark", Quantity: 1, Amount: "5.00"},
+	}, details.Items)
+}
+
+func TestGetTransactionDetailsError(t *testing.T) {
+	client := newTestClient(t, func(r url.Values) url.Values {
+		return url.Values{"ACK": {"Failure"}, "L_ERRORCODE0": {"10004"}, "L_SHORTMESSAGE0": {"Transaction refused because of an invalid argument"}}
+	})
+
+	details, err := client.GetTransactionDetails("invalid")
+	assert.Nil(t, details)
+	if assert.IsType(t, &paypal.PayPalError{}, err) {
+		assert.Equal(t, "10004", err.(*paypal.PayPalError).ErrorCode)
+	}
+}
+
+func TestGetTransactionDetailsInvalidTimestamp(t *testing.T) {
+	client := newTestClient(t, func(r url.Values) url.Values {
+		return url.Values{"ACK": {"Success"}, "TIMESTAMP": {"not a date"}, "TRANSACTIONID": {"TX1"}, "PAYMENTSTATUS": {"Completed"}}
+	})
+
+	details, err := client.GetTransactionDetails("TX1")
+	assert.IsType(t, &paypal.ResponseParseError{}, err)
+	if assert.NotNil(t, details) {
+		assert.Equal(t, paypal.PaymentStatusCompleted, details.PaymentStatus)
+	}
+}
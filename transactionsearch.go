@@ -0,0 +1,161 @@
+package paypal
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// TransactionSearchLimit is the number of transactions after which PayPal truncates the results of a search
+const TransactionSearchLimit = 100
+
+// searchTruncatedCode is the warning code of a search whose results were truncated at TransactionSearchLimit
+const searchTruncatedCode = "11002"
+
+// TransactionSearch holds the filters of a TransactionSearch request. StartDate is required, the other filters are
+// only sent when set. EndDate defaults to the time of the search.
+// See https://developer.paypal.com/docs/classic/api/merchant/TransactionSearch-API-Operation-NVP/ for the values
+// Status and TransactionClass accept
+type TransactionSearch struct {
+	StartDate        time.Time
+	EndDate          time.Time
+	Email            string
+	Receiver         string
+	TransactionID    string
+	InvoiceID        string
+	Amount           string
+	CurrencyCode     string
+	Status           string
+	TransactionClass string
+	ProfileID        string
+}
+
+// TransactionSearchResult is a transaction found by TransactionSearch
+type TransactionSearchResult struct {
+	Timestamp     time.Time     `json:"timestamp"`
+	TimeZone      string        `json:"timezone,omitempty"`
+	Type          string        `json:"type,omitempty"`
+	Email         string        `json:"email,omitempty"`
+	Name          string        `json:"name,omitempty"`
+	TransactionID string        `json:"transactionid,omitempty"`
+	Status        PaymentStatus `json:"status,omitempty"`
+	Amount        string        `json:"amt,omitempty"`
+	CurrencyCode  string        `json:"currencycode,omitempty"`
+	FeeAmount     string        `json:"feeamt,omitempty"`
+	NetAmount     string        `json:"netamt,omitempty"`
+}
+
+// values returns the request values of the search between start and end
+func (s TransactionSearch) values(start, end time.Time) url.Values {
+	values := url.Values{}
+	values.Set("METHOD", "TransactionSearch")
+	values.Set("STARTDATE", FormatTime(start))
+	values.Set("ENDDATE", FormatTime(end))
+
+	setIfPresent := func(key, value string) {
+		if len(value) != 0 {
+			values.Set(key, value)
+		}
+	}
+	setIfPresent("EMAIL", s.Email)
+	setIfPresent("RECEIVER", s.Receiver)
+	setIfPresent("TRANSACTIONID", s.TransactionID)
+	setIfPresent("INVNUM", s.InvoiceID)
+	setIfPresent("AMT", s.Amount)
+	setIfPresent("CURRENCYCODE", s.CurrencyCode)
+	setIfPresent("STATUS", s.Status)
+	setIfPresent("TRANSACTIONCLASS", s.TransactionClass)
+	setIfPresent("PROFILEID", s.ProfileID)
+	return values
+}
+
+// TransactionSearch returns every transaction matching the search, newest first.
+// PayPal returns at most TransactionSearchLimit transactions per request, so when a search is truncated its date
+// range is split in two halves that are searched separately, until each half fits. When more transactions than the
+// limit happened within the same second the range cannot be split any further: the transactions found are returned
+// along with the PayPalError of the truncated search. Likewise, the transactions found are returned along with a
+// *ResponseParseError when the TIMESTAMP of a response could not be parsed
+func (pClient *PayPalClient) TransactionSearch(search TransactionSearch) ([]TransactionSearchResult, error) {
+	if search.StartDate.IsZero() {
+		return nil, newValidationError("StartDate is required")
+	}
+	end := search.EndDate
+	if end.IsZero() {
+		end = time.Now()
+	}
+	return pClient.searchRange(search, search.StartDate.Truncate(time.Second), end.Truncate(time.Second))
+}
+
+// searchRange searches the transactions between start and end, both included, splitting the range when truncated
+func (pClient *PayPalClient) searchRange(search TransactionSearch, start, end time.Time) ([]TransactionSearchResult, error) {
+	response, err := pClient.PerformRequest(search.values(start, end))
+	if !isSearchTruncated(err) {
+		if requestFailed(err) {
+			return nil, err
+		}
+		results, parseErr := parseTransactionSearchResults(response.Values)
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		return results, err
+	}
+	if !end.After(start) {
+		results, parseErr := parseTransactionSearchResults(response.Values)
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		return results, err
+	}
+
+	middle := start.Add(end.Sub(start) / 2).Truncate(time.Second)
+	// Results are newest first, so the later half is searched first
+	newer, err := pClient.searchRange(search, middle.Add(time.Second), end)
+	if requestFailed(err) && !isSearchTruncated(err) {
+		return nil, err
+	}
+	older, olderErr := pClient.searchRange(search, start, middle)
+	if requestFailed(olderErr) && !isSearchTruncated(olderErr) {
+		return nil, olderErr
+	}
+	if olderErr != nil && (err == nil || isSearchTruncated(olderErr)) {
+		err = olderErr
+	}
+	return append(newer, older...), err
+}
+
+// isSearchTruncated reports whether err is the warning of a search truncated at TransactionSearchLimit
+func isSearchTruncated(err error) bool {
+	pError, ok := err.(*PayPalError)
+	return ok && pError.ErrorCode == searchTruncatedCode
+}
+
+// parseTransactionSearchResults reads the L_ prefixed rows of a TransactionSearch response
+func parseTransactionSearchResults(values url.Values) ([]TransactionSearchResult, error) {
+	var results []TransactionSearchResult
+	for i := 0; ; i++ {
+		n := strconv.Itoa(i)
+		if _, ok := values["L_TRANSACTIONID"+n]; !ok {
+			if _, ok := values["L_TIMESTAMP"+n]; !ok {
+				return results, nil
+			}
+		}
+
+		timestamp, err := parseTimeValue(values, "L_TIMESTAMP"+n)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, TransactionSearchResult{
+			Timestamp:     timestamp,
+			TimeZone:      values.Get("L_TIMEZONE" + n),
+			Type:          values.Get("L_TYPE" + n),
+			Email:         values.Get("L_EMAIL" + n),
+			Name:          values.Get("L_NAME" + n),
+			TransactionID: values.Get("L_TRANSACTIONID" + n),
+			Status:        ParsePaymentStatus(values.Get("L_STATUS" + n)),
+			Amount:        values.Get("L_AMT" + n),
+			CurrencyCode:  values.Get("L_CURRENCYCODE" + n),
+			FeeAmount:     values.Get("L_FEEAMT" + n),
+			NetAmount:     values.Get("L_NETAMT" + n),
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package paypal_test
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/japhy-team/paypal"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionSearch(t *testing.T) {
+	start := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+	// 250 transactions a minute apart, searched newest first
+	var timestamps []time.Time
+	for i := 0; i < 250; i++ {
+		timestamps = append(timestamps, start.Add(time.Duration(i)*time.Minute))
+	}
+
+	requests := 0
+	client := newTestClient(t, func(r url.Values) url.Values {
+		requests++
+		assert.Equal(t, "TransactionSearch", r.Get("METHOD"))
+		assert.Equal(t, "buyer@example.com", r.Get("EMAIL"))
+		assert.Equal(t, "Success", r.Get("STATUS"))
+		from, err := time.Parse("2006-01-02T15:04:05Z", r.Get("STARTDATE"))
+		assert.NoError(t, err)
+		to, err := time.Parse("2006-01-02T15:04:05Z", r.Get("ENDDATE"))
+		assert.NoError(t, err)
+
+		response := url.Values{"ACK": {"Success"}}
+		n := 0
+		for i := len(timestamps) - 1; i >= 0; i-- {
+			if timestamps[i].Before(from) || timestamps[i].After(to) {
+				continue
+			}
+			if n == paypal.TransactionSearchLimit {
+				response.Set("ACK", "SuccessWithWarning")
+				response.Set("L_ERRORCODE0", "11002")
+				response.Set("L_SHORTMESSAGE0", "Search warning")
+				break
+			}
+			suffix := fmt.Sprint(n)
+			response.Set("L_TIMESTAMP"+suffix, timestamps[i].Format("2006-01-02T15:04:05Z"))
+			response.Set("L_TRANSACTIONID"+suffix, fmt.Sprintf("TX%03d", i))
+			response.Set("L_TYPE"+suffix, "Payment")
+			response.Set("L_STATUS"+suffix, "Completed")
+			response.Set("L_AMT"+suffix, "10.00")
+			response.Set("L_NETAMT"+suffix, "9.41")
+			n++
+		}
+		return response
+	})
+
+	results, err := client.TransactionSearch(paypal.TransactionSearch{
+		StartDate: start,
+		EndDate:   start.Add(250 * time.Minute),
+		Email:     "buyer@example.com",
+		Status:    "Success",
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 250) {
+		for i, result := range results {
+			assert.Equal(t, fmt.Sprintf("TX%03d", 249-i), result.TransactionID)
+		}
+		assert.Equal(t, timestamps[249], results[0].Timestamp)
+		assert.Equal(t, "9.41", results[0].NetAmount)
+	}
+	assert.True(t, requests > 3)
+}
+
+func TestTransactionSearchWithinOneSecond(t *testing.T) {
+	client := newTestClient(t, func(r url.Values) url.Values {
+		return url.Values{
+			"ACK":              {"SuccessWithWarning"},
+			"L_ERRORCODE0":     {"11002"},
+			"L_TIMESTAMP0":     {"2020-03-01T00:00:00Z"},
+			"L_TRANSACTIONID0": {"TX000"},
+		}
+	})
+
+	second := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+	results, err := client.TransactionSearch(paypal.TransactionSearch{StartDate: second, EndDate: second})
+	assert.Len(t, results, 1)
+	if assert.IsType(t, &paypal.PayPalError{}, err) {
+		assert.Equal(t, "11002", err.(*paypal.PayPalError).ErrorCode)
+	}
+}
+
+func TestTransactionSearchInvalidTimestamp(t *testing.T) {
+	calls := 0
+	client := newTestClient(t, func(r url.Values) url.Values {
+		calls++
+		if calls == 1 {
+			return url.Values{"ACK": {"SuccessWithWarning"}, "L_ERRORCODE0": {"11002"}}
+		}
+		return url.Values{
+			"ACK":              {"Success"},
+			"TIMESTAMP":        {"not a date"},
+			"L_TIMESTAMP0":     {r.Get("STARTDATE")},
+			"L_TRANSACTIONID0": {"TX" + strconv.Itoa(calls)},
+		}
+	})
+
+	results, err := client.TransactionSearch(paypal.TransactionSearch{
+		StartDate: time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2020, 3, 2, 0, 0, 0, 0, time.UTC),
+	})
+	assert.IsType(t, &paypal.ResponseParseError{}, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, 3, calls)
+}
+
+func TestTransactionSearchRequiresStartDate(t *testing.T) {
+	client := newTestClient(t, func(r url.Values) url.Values {
+		t.Error("The search should not be sent")
+		return url.Values{}
+	})
+
+	_, err := client.TransactionSearch(paypal.TransactionSearch{Email: "buyer@example.com"})
+	assert.Error(t, err)
+}